@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultAsyncQueueSize = 1024
+)
+
+// OverflowPolicy decides what happens to a log when the queue of an asynchronous writer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the caller until the queue has a room.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the log being written.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest log in the queue to make a room.
+	OverflowDropOldest
+
+	// OverflowDropBelowLevel drops the log being written if its level is lower than
+	// AsyncOptions.MinLevel, and blocks otherwise.
+	OverflowDropBelowLevel
+)
+
+type AsyncOptions struct {
+	// QueueSize is a capacity of the queue of each writer. Defaults to 1024.
+	QueueSize int
+
+	Overflow OverflowPolicy
+
+	// MinLevel is used by OverflowDropBelowLevel. Defaults to Warn.
+	MinLevel *LogLevel
+}
+
+// Flusher is implemented by writers buffering logs.
+type Flusher interface {
+	// Flush blocks until all buffered logs are written, or the context is done.
+	Flush(ctx context.Context) error
+}
+
+// asyncWriter writes logs to the underlying writer on its own goroutine.
+type asyncWriter struct {
+	writer  OutputWriter
+	opts    AsyncOptions
+	queue   chan asyncItem
+	done    chan struct{}
+	dropped uint64
+
+	// closeLock prevents sending logs to the closed queue.
+	closeLock sync.RWMutex
+	closed    bool
+}
+
+type asyncItem struct {
+	log *Log
+
+	// flushed is closed by the worker when all logs queued before the item are written.
+	flushed chan struct{}
+}
+
+func newAsyncWriter(writer OutputWriter, opts AsyncOptions) *asyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultAsyncQueueSize
+	}
+	if opts.MinLevel == nil {
+		opts.MinLevel = Warn
+	}
+	aw := &asyncWriter{
+		writer: writer,
+		opts:   opts,
+		queue:  make(chan asyncItem, opts.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+func (aw *asyncWriter) run() {
+	defer close(aw.done)
+	for item := range aw.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		aw.writer.Write(item.log)
+	}
+}
+
+func (aw *asyncWriter) Init() {
+	aw.writer.Init()
+}
+
+func (aw *asyncWriter) Write(log *Log) {
+	aw.closeLock.RLock()
+	defer aw.closeLock.RUnlock()
+	if aw.closed {
+		return
+	}
+	item := asyncItem{log: log}
+
+	switch aw.opts.Overflow {
+	case OverflowDropNewest:
+		aw.tryEnqueue(item)
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case aw.queue <- item:
+				return
+			default:
+			}
+			select {
+			case oldest := <-aw.queue:
+				if oldest.flushed != nil {
+					// the worker may still be writing the logs queued before the flush marker,
+					// so the marker is queued again and the next log is dropped instead
+					aw.queue <- oldest
+					continue
+				}
+				atomic.AddUint64(&aw.dropped, 1)
+			default:
+			}
+		}
+
+	case OverflowDropBelowLevel:
+		if log.Level.Priority < aw.opts.MinLevel.Priority {
+			aw.tryEnqueue(item)
+			return
+		}
+		aw.queue <- item
+
+	default:
+		aw.queue <- item
+	}
+}
+
+func (aw *asyncWriter) tryEnqueue(item asyncItem) {
+	select {
+	case aw.queue <- item:
+	default:
+		atomic.AddUint64(&aw.dropped, 1)
+	}
+}
+
+func (aw *asyncWriter) Flush(ctx context.Context) error {
+	aw.closeLock.RLock()
+	if aw.closed {
+		aw.closeLock.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case aw.queue <- asyncItem{flushed: flushed}:
+		aw.closeLock.RUnlock()
+	case <-ctx.Done():
+		aw.closeLock.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if f, ok := aw.writer.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Dropped returns the number of logs dropped by the overflow policy.
+func (aw *asyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&aw.dropped)
+}
+
+// Close stops accepting logs and waits until the queued logs are written.
+func (aw *asyncWriter) Close() {
+	aw.closeLock.Lock()
+	if aw.closed {
+		aw.closeLock.Unlock()
+		return
+	}
+	aw.closed = true
+	close(aw.queue)
+	aw.closeLock.Unlock()
+
+	<-aw.done
+}
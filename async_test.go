@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// memoryWriter records written logs, optionally blocking until released.
+type memoryWriter struct {
+	mu      sync.Mutex
+	logs    []*Log
+	release chan struct{}
+}
+
+func (mw *memoryWriter) Init() {}
+
+func (mw *memoryWriter) Write(log *Log) {
+	if mw.release != nil {
+		<-mw.release
+	}
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.logs = append(mw.logs, log)
+}
+
+func (mw *memoryWriter) Logs() []*Log {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	return append([]*Log(nil), mw.logs...)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	convey.Convey("Given an asynchronous writer", t, func() {
+		convey.Convey("It should write all logs before Flush returns", func() {
+			mw := &memoryWriter{}
+			aw := newAsyncWriter(mw, AsyncOptions{})
+			defer aw.Close()
+
+			for i := 0; i < 100; i++ {
+				aw.Write(&Log{Level: Info})
+			}
+			convey.So(aw.Flush(context.Background()), convey.ShouldBeNil)
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 100)
+		})
+
+		convey.Convey("It should count logs dropped by the overflow policy", func() {
+			mw := &memoryWriter{release: make(chan struct{})}
+			aw := newAsyncWriter(mw, AsyncOptions{QueueSize: 2, Overflow: OverflowDropNewest})
+
+			// first log is taken by the worker, which is blocked
+			aw.Write(&Log{Level: Info})
+			time.Sleep(10 * time.Millisecond)
+			for i := 0; i < 5; i++ {
+				aw.Write(&Log{Level: Info})
+			}
+			convey.So(aw.Dropped(), convey.ShouldEqual, 3)
+
+			close(mw.release)
+			aw.Close()
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 3)
+		})
+
+		convey.Convey("It should keep logs at or above the level with OverflowDropBelowLevel", func() {
+			mw := &memoryWriter{release: make(chan struct{})}
+			aw := newAsyncWriter(mw, AsyncOptions{QueueSize: 1, Overflow: OverflowDropBelowLevel, MinLevel: Error})
+
+			aw.Write(&Log{Level: Info})
+			time.Sleep(10 * time.Millisecond)
+			aw.Write(&Log{Level: Info})
+			aw.Write(&Log{Level: Debug})
+			convey.So(aw.Dropped(), convey.ShouldEqual, 1)
+
+			close(mw.release)
+			aw.Write(&Log{Level: Error})
+			aw.Close()
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 3)
+			convey.So(mw.Logs()[2].Level, convey.ShouldEqual, Error)
+		})
+
+		convey.Convey("Flush should wait for the logs being written with OverflowDropOldest", func() {
+			mw := &memoryWriter{release: make(chan struct{})}
+			aw := newAsyncWriter(mw, AsyncOptions{QueueSize: 2, Overflow: OverflowDropOldest})
+
+			aw.Write(&Log{Level: Info, Message: "first"})
+			time.Sleep(10 * time.Millisecond)
+			flushed := make(chan error, 1)
+			go func() { flushed <- aw.Flush(context.Background()) }()
+			time.Sleep(10 * time.Millisecond)
+
+			// the flush marker is at the head of the full queue
+			aw.Write(&Log{Level: Info})
+			aw.Write(&Log{Level: Info})
+			select {
+			case <-flushed:
+				t.Error("Flush returned before the first log was written")
+			case <-time.After(10 * time.Millisecond):
+			}
+			convey.So(aw.Dropped(), convey.ShouldEqual, 1)
+
+			close(mw.release)
+			convey.So(<-flushed, convey.ShouldBeNil)
+			convey.So(mw.Logs()[0].Message, convey.ShouldEqual, "first")
+			aw.Close()
+		})
+
+		convey.Convey("Flush should respect the context deadline", func() {
+			mw := &memoryWriter{release: make(chan struct{})}
+			aw := newAsyncWriter(mw, AsyncOptions{})
+			aw.Write(&Log{Level: Info})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			convey.So(aw.Flush(ctx) == context.DeadlineExceeded, convey.ShouldBeTrue)
+
+			close(mw.release)
+			aw.Close()
+		})
+	})
+}
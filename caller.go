@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"path"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxCallerDepth = 32
+)
+
+// packageDir is used to skip frames inside of the logger package.
+var packageDir string
+
+func init() {
+	_, file, _, _ := goruntime.Caller(0)
+	packageDir = path.Dir(file)
+}
+
+// captureCaller returns the location of the first frame outside of the logger package,
+// skipping given number of frames further.
+func captureCaller(skip int) (file string, line int, function string) {
+	var pcs [maxCallerDepth]uintptr
+	n := goruntime.Callers(2, pcs[:])
+	frames := goruntime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame.File) {
+			if skip == 0 {
+				return frame.File, frame.Line, frame.Function
+			}
+			skip--
+		}
+		if !more {
+			return "", 0, ""
+		}
+	}
+}
+
+func isInternalFrame(file string) bool {
+	return path.Dir(file) == packageDir && !strings.HasSuffix(file, "_test.go")
+}
+
+// Caller returns the call site of the log in "dir/file.go:line" form, or an empty string
+// if caller capture is disabled.
+func (log *Log) Caller() string {
+	if log.File == "" {
+		return ""
+	}
+	file := log.File
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(log.Line)
+}
+
+type callerMode int
+
+const (
+	// callerDefault follows the setting of the runtime.
+	callerDefault callerMode = iota
+	callerEnabled
+	callerDisabled
+)
+
+// Option configures a logger created by New.
+type Option func(l *logger)
+
+// WithCaller enables or disables recording the call site (file, line and function) of logs,
+// overriding the setting of the runtime.
+func WithCaller(enabled bool) Option {
+	return func(l *logger) {
+		if enabled {
+			l.caller = callerEnabled
+		} else {
+			l.caller = callerDisabled
+		}
+	}
+}
+
+// WithCallerSkip skips given number of frames when recording the call site,
+// e.g. for logging helpers wrapping the logger.
+func WithCallerSkip(skip int) Option {
+	return func(l *logger) {
+		l.callerSkip = skip
+	}
+}
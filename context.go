@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	attrsContextKey
+)
+
+// WithContext returns a copy of ctx carrying given logger.
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// FromContext returns the logger attached by WithContext.
+// If there is no logger in the context, it returns a logger named "default".
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerContextKey).(Logger); ok {
+		return l
+	}
+	return New("default")
+}
+
+// ContextWithAttrs returns a copy of ctx carrying given attributes, merged with the attributes
+// already attached to the context. The attributes are logged by context-aware methods (e.g. InfoCtx).
+func ContextWithAttrs(ctx context.Context, attrs Attrs) context.Context {
+	return context.WithValue(ctx, attrsContextKey, AttrsFromContext(ctx).Merge(attrs))
+}
+
+// AttrsFromContext returns attributes attached by ContextWithAttrs.
+func AttrsFromContext(ctx context.Context) Attrs {
+	if attrs, ok := ctx.Value(attrsContextKey).(Attrs); ok {
+		return attrs
+	}
+	return nil
+}
+
+// withContextAttrs merges the context attributes to given arguments.
+// Attributes given as an argument take precedence over the context's.
+func withContextAttrs(ctx context.Context, args []interface{}) []interface{} {
+	ctxAttrs := AttrsFromContext(ctx)
+	if len(ctxAttrs) == 0 {
+		return args
+	}
+	merged := make([]interface{}, len(args), len(args)+1)
+	copy(merged, args)
+	return append(merged, defaultFields(Fields(nil).setAttrs(ctxAttrs)))
+}
+
+// NewRequestID returns a random ID for the "request_id" attribute, used by the middlewares
+// when a request doesn't carry one.
+func NewRequestID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestContext(t *testing.T) {
+	convey.Convey("Loggers should be carried by contexts", t, func() {
+		l := New("api")
+		ctx := WithContext(context.Background(), l)
+		convey.So(FromContext(ctx), convey.ShouldEqual, l)
+
+		fallback := FromContext(context.Background())
+		convey.So(fallback, convey.ShouldNotBeNil)
+		convey.So(fallback.(*logger).Name, convey.ShouldEqual, "default")
+	})
+
+	convey.Convey("Given attributes attached to nested contexts", t, func() {
+		outer := ContextWithAttrs(context.Background(), Attrs{"request_id": "r1", "user": "alice"})
+		inner := ContextWithAttrs(outer, Attrs{"user": "bob", "step": 2})
+
+		convey.Convey("They should be merged, inner ones taking precedence", func() {
+			convey.So(AttrsFromContext(inner), convey.ShouldResemble, Attrs{"request_id": "r1", "user": "bob", "step": 2})
+			convey.So(AttrsFromContext(outer), convey.ShouldResemble, Attrs{"request_id": "r1", "user": "alice"})
+			convey.So(AttrsFromContext(context.Background()), convey.ShouldBeNil)
+		})
+
+		convey.Convey("They should be logged by context-aware methods, after given attributes", func() {
+			mw := &memoryWriter{}
+			defer Hook(mw).Unhook()
+
+			l := New("api")
+			l.InfoCtx(inner, "hello {user}", Attrs{"user": "carol"})
+			l.ErrorCtx(inner, "failed", context.Canceled)
+
+			logs := mw.Logs()
+			convey.So(logs[0].Message, convey.ShouldEqual, "hello carol")
+			convey.So(*logs[0].Attrs, convey.ShouldResemble, Attrs{"request_id": "r1", "user": "carol", "step": 2})
+			convey.So(logs[1].Message, convey.ShouldEqual, "failed: context canceled")
+			convey.So((*logs[1].Attrs)["request_id"], convey.ShouldEqual, "r1")
+		})
+	})
+
+	convey.Convey("Request IDs should be random hex strings", t, func() {
+		id := NewRequestID()
+		convey.So(id, convey.ShouldHaveLength, 16)
+		convey.So(NewRequestID(), convey.ShouldNotEqual, id)
+	})
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupWriter collapses identical consecutive logs (same package, level, message and attributes)
+// written within a window, and writes a single summary log with the repeat count instead.
+type DedupWriter struct {
+	writer OutputWriter
+	window time.Duration
+
+	lock      sync.Mutex
+	last      *Log
+	lastKey   string
+	windowEnd int64
+	repeated  int
+	firstTime int64
+	lastTime  int64
+	timer     *time.Timer
+}
+
+// NewDedupWriter wraps given writer to suppress repeated messages within the window.
+func NewDedupWriter(writer OutputWriter, window time.Duration) *DedupWriter {
+	return &DedupWriter{
+		writer: writer,
+		window: window,
+	}
+}
+
+func (dw *DedupWriter) Init() {
+	dw.writer.Init()
+}
+
+func (dw *DedupWriter) Unwrap() OutputWriter {
+	return dw.writer
+}
+
+func (dw *DedupWriter) fieldsOnly() {}
+
+func (dw *DedupWriter) Write(log *Log) {
+	// logs dropped by the writer must not start or end runs of repeated logs
+	if !dw.enabled(log) {
+		return
+	}
+	key := dedupKey(log)
+
+	dw.lock.Lock()
+	defer dw.lock.Unlock()
+
+	if dw.last != nil && key == dw.lastKey && log.Time < dw.windowEnd {
+		dw.repeated++
+		dw.lastTime = log.Time
+		return
+	}
+	dw.writeSummary()
+	dw.writer.Write(log)
+
+	dw.last = log
+	dw.lastKey = key
+	dw.repeated = 0
+	dw.firstTime = log.Time
+	dw.lastTime = log.Time
+	dw.windowEnd = log.Time + int64(dw.window)
+
+	last := log
+	if dw.timer != nil {
+		dw.timer.Stop()
+	}
+	dw.timer = time.AfterFunc(dw.window, func() {
+		dw.lock.Lock()
+		defer dw.lock.Unlock()
+		if dw.last == last {
+			dw.writeSummary()
+			dw.last = nil
+		}
+	})
+}
+
+// enabled returns whether the wrapped writer emits the log, by its IsEnabled method or level filter.
+func (dw *DedupWriter) enabled(log *Log) bool {
+	if ew, ok := dw.writer.(EnablingWriter); ok {
+		return ew.IsEnabled(log.Package, log.Level)
+	}
+	if f := writerFilter(dw.writer); f != nil {
+		return f.Enabled(log.Package, log.Level)
+	}
+	return true
+}
+
+// Flush writes the summary of pending repeated logs.
+func (dw *DedupWriter) Flush(ctx context.Context) error {
+	dw.lock.Lock()
+	dw.writeSummary()
+	dw.lock.Unlock()
+
+	if f, ok := dw.writer.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// writeSummary must be called with the lock held.
+func (dw *DedupWriter) writeSummary() {
+	if dw.last == nil || dw.repeated == 0 {
+		return
+	}
+	attrs := Fields{
+		Int("repeated", dw.repeated),
+		String("first", time.Unix(0, dw.firstTime).Format(time.RFC3339Nano)),
+		String("last", time.Unix(0, dw.lastTime).Format(time.RFC3339Nano)),
+	}
+	summary := &Log{
+		Package: dw.last.Package,
+		Level:   dw.last.Level,
+		Message: fmt.Sprintf("last message repeated %d times", dw.repeated),
+		Time:    dw.lastTime,
+
+		Fields:          attrs,
+		DisplayedFields: attrs,
+	}
+	if !readsFields(dw.writer) {
+		summary.buildAttrs()
+	}
+	dw.writer.Write(summary)
+	dw.repeated = 0
+}
+
+// dedupKey identifies logs considered identical.
+func dedupKey(log *Log) string {
+	key := log.Package + "\x00" + log.Level.Name + "\x00" + log.Message
+	fields := log.fields()
+	if len(fields) == 0 {
+		return key
+	}
+	attrs := fields.Flatten()
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+
+	var b strings.Builder
+	b.WriteString(key)
+	for _, f := range attrs {
+		fmt.Fprintf(&b, "\x00%s=%v", f.Key, f.Value())
+	}
+	return b.String()
+}
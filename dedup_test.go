@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestDedupWriter(t *testing.T) {
+	convey.Convey("Given a deduplicating writer", t, func() {
+		mw := &memoryWriter{}
+		dw := NewDedupWriter(mw, time.Hour)
+		write := func(msg string) {
+			dw.Write(&Log{Package: "db", Level: Error, Message: msg, Time: Now(), Attrs: &Attrs{"host": "a"}})
+		}
+
+		convey.Convey("It should collapse identical consecutive logs into a summary", func() {
+			for i := 0; i < 5; i++ {
+				write("connection refused")
+			}
+			write("connected")
+
+			logs := mw.Logs()
+			convey.So(logs, convey.ShouldHaveLength, 3)
+			convey.So(logs[0].Message, convey.ShouldEqual, "connection refused")
+			convey.So(logs[1].Message, convey.ShouldEqual, "last message repeated 4 times")
+			convey.So((*logs[1].Attrs)["repeated"], convey.ShouldEqual, 4)
+			convey.So(logs[2].Message, convey.ShouldEqual, "connected")
+		})
+
+		convey.Convey("It should write the summary when the window ends", func() {
+			dw.window = 10 * time.Millisecond
+			write("connection refused")
+			write("connection refused")
+			time.Sleep(50 * time.Millisecond)
+
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 2)
+			convey.So(mw.Logs()[1].Message, convey.ShouldEqual, "last message repeated 1 times")
+		})
+	})
+
+	convey.Convey("Logs filtered by the wrapped writer should not break runs", t, func() {
+		fw := &filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}
+		dw := NewDedupWriter(fw, time.Hour)
+		for i := 0; i < 3; i++ {
+			dw.Write(&Log{Package: "db", Level: Warn, Message: "slow query", Time: Now()})
+			dw.Write(&Log{Package: "db", Level: Debug, Message: "query", Time: Now()})
+		}
+		dw.Write(&Log{Package: "db", Level: Info, Message: "done", Time: Now()})
+
+		logs := fw.Logs()
+		convey.So(logs, convey.ShouldHaveLength, 3)
+		convey.So(logs[1].Message, convey.ShouldEqual, "last message repeated 2 times")
+	})
+}
@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Encoder converts a value to its log representation, e.g. a string. Returning Attrs or Fields logs
+// the value as a group.
+type Encoder func(value interface{}) interface{}
+
+// BytesEncoding tells how byte slices are logged.
+type BytesEncoding int
+
+const (
+	// BytesHex encodes byte slices as hex strings.
+	BytesHex BytesEncoding = iota
+
+	// BytesBase64 encodes byte slices as standard base64 strings.
+	BytesBase64
+)
+
+// encoderRegistry is an immutable set of encoders, replaced on every registration.
+type encoderRegistry struct {
+	types      map[reflect.Type]Encoder
+	interfaces []interfaceEncoder
+	bytes      BytesEncoding
+
+	// byKind holds encoders of the types of typed fields (e.g. time.Duration for Duration),
+	// so typed fields are boxed only if they have an encoder.
+	byKind [numFieldKinds]Encoder
+}
+
+// kindTypes are the types of values of typed fields by kind.
+var kindTypes = [numFieldKinds]reflect.Type{
+	stringField:   reflect.TypeOf(""),
+	intField:      reflect.TypeOf(0),
+	int64Field:    reflect.TypeOf(int64(0)),
+	float64Field:  reflect.TypeOf(float64(0)),
+	boolField:     reflect.TypeOf(false),
+	durationField: reflect.TypeOf(time.Duration(0)),
+}
+
+type interfaceEncoder struct {
+	iface  reflect.Type
+	encode Encoder
+}
+
+var (
+	encoders     atomic.Value
+	encodersLock sync.Mutex
+)
+
+// RegisterEncoder registers the encoder for values of the same type as the sample, e.g. new(big.Int)
+// for *big.Int. To register the encoder for an interface, pass a nil pointer to it,
+// e.g. (*fmt.Stringer)(nil). Encoders of concrete types take precedence over ones of interfaces,
+// which are tried in order of registration. Registering an encoder for a type again replaces it.
+//
+// Encoders apply to attributes of every log, before messages are formatted, so the console,
+// JSON and custom writers all receive the encoded values. Values implementing LogValuer are
+// resolved first.
+func RegisterEncoder(sample interface{}, encode Encoder) {
+	t := reflect.TypeOf(sample)
+	if t == nil {
+		panic("logger: RegisterEncoder with nil sample")
+	}
+	updateEncoders(func(r *encoderRegistry) {
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+			iface := t.Elem()
+			for i, e := range r.interfaces {
+				if e.iface == iface {
+					r.interfaces[i].encode = encode
+					return
+				}
+			}
+			r.interfaces = append(r.interfaces, interfaceEncoder{iface: iface, encode: encode})
+			return
+		}
+		r.types[t] = encode
+	})
+}
+
+// SetBytesEncoding changes how byte slices are logged. It's BytesHex by default.
+func SetBytesEncoding(encoding BytesEncoding) {
+	updateEncoders(func(r *encoderRegistry) {
+		r.bytes = encoding
+	})
+}
+
+func updateEncoders(update func(r *encoderRegistry)) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+
+	current := loadEncoders()
+	r := &encoderRegistry{
+		types:      make(map[reflect.Type]Encoder, len(current.types)+1),
+		interfaces: append([]interfaceEncoder(nil), current.interfaces...),
+		bytes:      current.bytes,
+	}
+	for t, encode := range current.types {
+		r.types[t] = encode
+	}
+	update(r)
+	for kind, t := range kindTypes {
+		if t != nil {
+			r.byKind[kind] = r.typeEncoder(t)
+		}
+	}
+	encoders.Store(r)
+}
+
+var emptyEncoders = &encoderRegistry{}
+
+func loadEncoders() *encoderRegistry {
+	if r, ok := encoders.Load().(*encoderRegistry); ok {
+		return r
+	}
+	return emptyEncoders
+}
+
+// encoder returns the encoder of the value, or nil if it has none.
+func (r *encoderRegistry) encoder(value interface{}) Encoder {
+	if _, ok := value.([]byte); ok {
+		return r.encodeBytes
+	}
+	if len(r.types) == 0 && len(r.interfaces) == 0 {
+		return nil
+	}
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return nil
+	}
+	return r.typeEncoder(t)
+}
+
+func (r *encoderRegistry) typeEncoder(t reflect.Type) Encoder {
+	if encode, ok := r.types[t]; ok {
+		return encode
+	}
+	for _, e := range r.interfaces {
+		if t.Implements(e.iface) {
+			return e.encode
+		}
+	}
+	return nil
+}
+
+// encodeDuration returns the duration encoded by the encoder registered for time.Duration, or false
+// if there is none. Writers use it for elapsed times of timers.
+func encodeDuration(d time.Duration) (interface{}, bool) {
+	r := loadEncoders()
+	if r.byKind[durationField] == nil {
+		return nil, false
+	}
+	return r.resolveValue(d), true
+}
+
+func (r *encoderRegistry) encodeBytes(value interface{}) interface{} {
+	b := value.([]byte)
+	if r.bytes == BytesBase64 {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+type encoderTestID struct{ n int }
+
+func (id encoderTestID) String() string {
+	return fmt.Sprintf("id-%d", id.n)
+}
+
+func TestEncoders(t *testing.T) {
+	convey.Convey("Given registered encoders", t, func() {
+		previous := loadEncoders()
+		defer encoders.Store(previous)
+
+		RegisterEncoder(new(big.Int), func(v interface{}) interface{} { return v.(*big.Int).Text(16) })
+		RegisterEncoder(net.IP{}, func(v interface{}) interface{} { return Attrs{"ip": v.(net.IP).String(), "v4": v.(net.IP).To4() != nil} })
+		RegisterEncoder((*fmt.Stringer)(nil), func(v interface{}) interface{} { return "stringer:" + v.(fmt.Stringer).String() })
+
+		w := &memoryWriter{}
+		l := NewRuntime(w).New("api")
+
+		convey.Convey("Values should be encoded before formatting", func() {
+			l.Info("balance {}", big.NewInt(255), Attrs{"id": encoderTestID{7}})
+			log := w.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "balance ff")
+			convey.So((*log.Attrs)["id"], convey.ShouldEqual, "stringer:id-7")
+		})
+
+		convey.Convey("Encoders returning Attrs should make groups", func() {
+			l.Info("from {peer.ip}", Attrs{"peer": net.ParseIP("10.0.0.1")})
+			log := w.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "from 10.0.0.1")
+			convey.So((*log.Attrs)["peer"], convey.ShouldResemble, Attrs{"ip": "10.0.0.1", "v4": true})
+		})
+
+		convey.Convey("Format should use encoders", func() {
+			formatted, _ := Format("{n}", Attrs{"n": big.NewInt(16)})
+			convey.So(formatted, convey.ShouldEqual, "10")
+		})
+	})
+
+	convey.Convey("Given an encoder of a type of typed fields", t, func() {
+		previous := loadEncoders()
+		defer encoders.Store(previous)
+
+		RegisterEncoder(time.Duration(0), func(v interface{}) interface{} { return v.(time.Duration).Milliseconds() })
+		buf := new(bytes.Buffer)
+		jw := &JSONWriter{Target: buf, Filter: NewLevelFilter(map[string]LogPriority{"*": 0}), FieldNames: DefaultJSONFieldNames}
+		l := NewRuntime(jw).New("api")
+
+		convey.Convey("Typed fields should be encoded like other values", func() {
+			l.Info("took {} ({d})", time.Second, Duration("d", 2*time.Second), Int("n", 1))
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["msg"], convey.ShouldEqual, "took 1000 (2000)")
+			convey.So(out["n"], convey.ShouldEqual, 1)
+		})
+
+		convey.Convey("Elapsed times of timers should be encoded", func() {
+			timer := l.Timer()
+			timer.Time -= int64(1500 * time.Millisecond)
+			timer.End("done")
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["elapsed"], convey.ShouldBeBetweenOrEqual, 1500, 1600)
+
+			sw := &StandardWriter{}
+			convey.So(sw.PrettyLabelExt(&Log{Level: Timer, ElapsedNano: int64(time.Second)}), convey.ShouldEqual, "(1000)")
+		})
+	})
+
+	convey.Convey("Given bytes encoding", t, func() {
+		previous := loadEncoders()
+		defer encoders.Store(previous)
+
+		buf := new(bytes.Buffer)
+		jw := &JSONWriter{Target: buf, Filter: NewLevelFilter(map[string]LogPriority{"*": 0}), FieldNames: DefaultJSONFieldNames}
+		l := NewRuntime(jw).New("api")
+		sw := &StandardWriter{}
+
+		convey.Convey("Bytes should be hex by default in every output", func() {
+			l.Info("got {}", []byte("hi"), Bytes("raw", []byte{0xff}))
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["msg"], convey.ShouldEqual, "got 6869")
+			convey.So(out["raw"], convey.ShouldEqual, "ff")
+		})
+
+		convey.Convey("Bytes should be base64 if set", func() {
+			SetBytesEncoding(BytesBase64)
+			l.Info("got {}", []byte("hi"), Bytes("raw", []byte{0xff}))
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["msg"], convey.ShouldEqual, "got aGk=")
+			convey.So(out["raw"], convey.ShouldEqual, "/w==")
+
+			convey.So(sw.PrettyAttrs(&Log{Level: Info, DisplayedFields: Fields{Bytes("raw", []byte{0xff})}}), convey.ShouldEqual, ` raw="/w=="`)
+		})
+	})
+}
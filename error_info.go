@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"fmt"
+	goruntime "runtime"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorInfo is a structured form of an error attached to a log by Error or Wtf.
+type ErrorInfo struct {
+	Message string `json:"msg"`
+
+	// Chain holds messages of the wrapped errors, from the outermost one.
+	// Wrappers without own message (e.g. pkg/errors.WithStack) are omitted.
+	Chain []string `json:"chain,omitempty"`
+
+	// Types holds concrete types of the errors in Chain.
+	Types []string `json:"types,omitempty"`
+
+	// Stack is the stack trace of the innermost error providing it (e.g. created by pkg/errors),
+	// in "function (file:line)" form.
+	Stack []string `json:"stack,omitempty"`
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// NewErrorInfo captures the error message, the chain of wrapped errors and the stack trace.
+func NewErrorInfo(err error) *ErrorInfo {
+	info := &ErrorInfo{Message: err.Error()}
+
+	var stack errors.StackTrace
+	for e := err; e != nil; e = unwrapError(e) {
+		msg := e.Error()
+		if n := len(info.Chain); n == 0 || info.Chain[n-1] != msg {
+			info.Chain = append(info.Chain, msg)
+			info.Types = append(info.Types, fmt.Sprintf("%T", e))
+		}
+		if st, ok := e.(stackTracer); ok {
+			stack = st.StackTrace()
+		}
+	}
+	for _, frame := range stack {
+		info.Stack = append(info.Stack, formatFrame(frame))
+	}
+	return info
+}
+
+// unwrapError supports both Go 1.13 error chains and pkg/errors causes.
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+func formatFrame(frame errors.Frame) string {
+	pc := uintptr(frame) - 1
+	fn := goruntime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	file, line := fn.FileLine(pc)
+	return fmt.Sprintf("%s (%s:%d)", fn.Name(), file, line)
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewErrorInfo(t *testing.T) {
+	convey.Convey("Given a wrapped error from pkg/errors", t, func() {
+		origin := errors.New("connection refused")
+		err := fmt.Errorf("failed to save user: %w", errors.Wrap(origin, "dial"))
+		info := NewErrorInfo(err)
+
+		convey.Convey("It should capture the chain and the types", func() {
+			convey.So(info.Message, convey.ShouldEqual, "failed to save user: dial: connection refused")
+			convey.So(info.Chain, convey.ShouldResemble, []string{
+				"failed to save user: dial: connection refused",
+				"dial: connection refused",
+				"connection refused",
+			})
+			convey.So(info.Types, convey.ShouldResemble, []string{"*fmt.wrapError", "*errors.withStack", "*errors.fundamental"})
+		})
+
+		convey.Convey("It should capture the stack trace of the origin", func() {
+			convey.So(info.Stack, convey.ShouldNotBeEmpty)
+			convey.So(info.Stack[0], convey.ShouldStartWith, "github.com/airbloc/logger.TestNewErrorInfo")
+		})
+	})
+
+	convey.Convey("Error should attach the error to the log", t, func() {
+		mw := &memoryWriter{}
+		NewRuntime(mw).New("app").WithAttrs(Attrs{"a": 1}).Error("Failed", errors.New("boom"))
+
+		log := mw.Logs()[0]
+		convey.So(log.Message, convey.ShouldEqual, "Failed: boom")
+		convey.So(log.Err.Message, convey.ShouldEqual, "boom")
+		convey.So(log.Err.Stack, convey.ShouldNotBeEmpty)
+	})
+}
@@ -0,0 +1,341 @@
+package logger
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type fieldKind uint8
+
+const (
+	anyField fieldKind = iota
+	stringField
+	intField
+	int64Field
+	float64Field
+	boolField
+	durationField
+
+	numFieldKinds
+)
+
+// Field is a typed attribute. Values of common types (strings, numbers, booleans and durations)
+// are stored without boxing them into interface{}, so logging them doesn't allocate.
+type Field struct {
+	Key string
+
+	kind  fieldKind
+	num   int64
+	str   string
+	value interface{}
+}
+
+// Fields is an ordered list of attributes, which is how attributes are stored in a Log.
+// It can be passed to Logger methods along with or instead of Attrs.
+type Fields []Field
+
+// String returns a field with a string value.
+func String(key, value string) Field {
+	return Field{Key: key, kind: stringField, str: value}
+}
+
+// Int returns a field with an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: intField, num: int64(value)}
+}
+
+// Int64 returns a field with an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: int64Field, num: value}
+}
+
+// Float64 returns a field with a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: float64Field, num: int64(math.Float64bits(value))}
+}
+
+// Bool returns a field with a bool value.
+func Bool(key string, value bool) Field {
+	f := Field{Key: key, kind: boolField}
+	if value {
+		f.num = 1
+	}
+	return f
+}
+
+// Duration returns a field with a time.Duration value.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationField, num: int64(value)}
+}
+
+// Time returns a field with a time.Time value.
+func Time(key string, value time.Time) Field {
+	return Any(key, value)
+}
+
+// Err returns a field with the error keyed by "error".
+func Err(err error) Field {
+	return Any("error", err)
+}
+
+// Bytes returns a field with a byte slice value.
+func Bytes(key string, value []byte) Field {
+	return Any(key, value)
+}
+
+// Group returns a field nesting the fields under the key. Attrs given as a value are also
+// turned into a group.
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, value: Fields(fields)}
+}
+
+// Any returns a field with an arbitrary value.
+func Any(key string, value interface{}) Field {
+	if attrs, ok := value.(Attrs); ok {
+		return Group(key, Fields(nil).setAttrs(attrs)...)
+	}
+	return Field{Key: key, value: value}
+}
+
+// group returns the fields nested in the field, if it's a group.
+func (f Field) group() (Fields, bool) {
+	fields, ok := f.value.(Fields)
+	return fields, ok && f.kind == anyField
+}
+
+// Value returns the value of the field.
+func (f Field) Value() interface{} {
+	switch f.kind {
+	case stringField:
+		return f.str
+	case intField:
+		return int(f.num)
+	case int64Field:
+		return f.num
+	case float64Field:
+		return math.Float64frombits(uint64(f.num))
+	case boolField:
+		return f.num == 1
+	case durationField:
+		return time.Duration(f.num)
+	}
+	return f.value
+}
+
+// appendValue writes the value of the field with "%v" format,
+// without boxing values of common types.
+func (f Field) appendValue(b *bytes.Buffer) {
+	var scratch [32]byte
+	switch f.kind {
+	case stringField:
+		b.WriteString(f.str)
+	case intField, int64Field:
+		b.Write(strconv.AppendInt(scratch[:0], f.num, 10))
+	case float64Field:
+		b.Write(strconv.AppendFloat(scratch[:0], math.Float64frombits(uint64(f.num)), 'g', -1, 64))
+	case boolField:
+		b.Write(strconv.AppendBool(scratch[:0], f.num == 1))
+	case durationField:
+		b.WriteString(time.Duration(f.num).String())
+	default:
+		writeValue(b, f.value)
+	}
+}
+
+// Get returns the value of the attribute.
+func (fs Fields) Get(key string) (interface{}, bool) {
+	if i := fs.index(key); i >= 0 {
+		return fs[i].Value(), true
+	}
+	return nil, false
+}
+
+// Attrs returns the fields as a map. Groups are returned as nested Attrs.
+func (fs Fields) Attrs() Attrs {
+	attrs := make(Attrs, len(fs))
+	for _, f := range fs {
+		if group, ok := f.group(); ok {
+			attrs[f.Key] = group.Attrs()
+			continue
+		}
+		attrs[f.Key] = f.Value()
+	}
+	return attrs
+}
+
+// Flatten returns the fields with groups replaced by their fields, keyed by dotted paths
+// (e.g. "http.status").
+func (fs Fields) Flatten() Fields {
+	flat := make(Fields, 0, len(fs))
+	return fs.appendFlattened(flat, "")
+}
+
+func (fs Fields) appendFlattened(flat Fields, prefix string) Fields {
+	for _, f := range fs {
+		if group, ok := f.group(); ok {
+			flat = group.appendFlattened(flat, prefix+f.Key+".")
+			continue
+		}
+		f.Key = prefix + f.Key
+		flat = append(flat, f)
+	}
+	return flat
+}
+
+// MarshalJSON encodes the fields as an object, in order.
+func (fs Fields) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONValue(buf, f.Key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, f.Value())
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// without returns the fields except the ones at given paths. Paths of fields in groups are dotted
+// (e.g. "http.status"), and prefix is the path of the group of the fields.
+func (fs Fields) without(paths []string, prefix string) Fields {
+	kept := make(Fields, 0, len(fs))
+	for _, f := range fs {
+		path := prefix + f.Key
+		if containsString(paths, path) {
+			continue
+		}
+		if group, ok := f.group(); ok && hasPathPrefix(paths, path+".") {
+			f = Group(f.Key, group.without(paths, path+".")...)
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+func hasPathPrefix(paths []string, prefix string) bool {
+	for _, path := range paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs Fields) index(key string) int {
+	for i := range fs {
+		if fs[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// set replaces the field with the same key, or appends the field. Groups with the same key are merged.
+func (fs Fields) set(f Field) Fields {
+	if i := fs.index(f.Key); i >= 0 {
+		fs[i] = mergeGroups(fs[i], f, false)
+		return fs
+	}
+	return append(fs, f)
+}
+
+// setDefault appends the field unless a field with the same key exists.
+// Groups with the same key are merged, keeping the existing fields.
+func (fs Fields) setDefault(f Field) Fields {
+	if i := fs.index(f.Key); i >= 0 {
+		fs[i] = mergeGroups(fs[i], f, true)
+		return fs
+	}
+	return append(fs, f)
+}
+
+// mergeGroups returns the field overriding the existing one, or a group with fields of both
+// if they're groups. If keepExisting is set, the existing fields take precedence.
+func mergeGroups(existing, f Field, keepExisting bool) Field {
+	a, aIsGroup := existing.group()
+	b, bIsGroup := f.group()
+	if !aIsGroup || !bIsGroup {
+		if keepExisting {
+			return existing
+		}
+		return f
+	}
+	merged := make(Fields, len(a), len(a)+len(b))
+	copy(merged, a)
+	for _, nested := range b {
+		if keepExisting {
+			merged = merged.setDefault(nested)
+		} else {
+			merged = merged.set(nested)
+		}
+	}
+	return Group(existing.Key, merged...)
+}
+
+// setAttrs sets attributes sorted by key, so the order of fields is deterministic.
+func (fs Fields) setAttrs(attrs Attrs) Fields {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fs = fs.set(Any(key, attrs[key]))
+	}
+	return fs
+}
+
+// defaultFields are appended to arguments by sub-loggers and context-aware methods.
+// Unlike Fields, they don't override attributes given before.
+type defaultFields Fields
+
+// collectFields converts arguments of a log to fields. Attrs, Field and Fields are merged in order,
+// later keys overriding earlier ones, and the other arguments are positional (keyed by "0", "1", ...).
+func collectFields(args []interface{}) Fields {
+	n := 0
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case Attrs:
+			n += len(a)
+		case Fields:
+			n += len(a)
+		case defaultFields:
+			n += len(a)
+		default:
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	fields := make(Fields, 0, n)
+	index := 0
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case Attrs:
+			fields = fields.setAttrs(a)
+		case Field:
+			fields = fields.set(a)
+		case Fields:
+			for _, f := range a {
+				fields = fields.set(f)
+			}
+		case defaultFields:
+			for _, f := range a {
+				fields = fields.setDefault(f)
+			}
+		default:
+			fields = fields.set(Any(strconv.Itoa(index), arg))
+			index++
+		}
+	}
+	return fields
+}
@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestFields(t *testing.T) {
+	convey.Convey("Fields should keep values of their types", t, func() {
+		convey.So(String("k", "v").Value(), convey.ShouldEqual, "v")
+		convey.So(Int("k", -3).Value(), convey.ShouldEqual, -3)
+		convey.So(Int64("k", 1<<40).Value(), convey.ShouldEqual, int64(1<<40))
+		convey.So(Float64("k", 0.25).Value(), convey.ShouldEqual, 0.25)
+		convey.So(Bool("k", true).Value(), convey.ShouldEqual, true)
+		convey.So(Duration("k", time.Second).Value(), convey.ShouldEqual, time.Second)
+		convey.So(Bytes("k", []byte{1}).Value(), convey.ShouldResemble, []byte{1})
+
+		err := errors.New("oops")
+		convey.So(Err(err).Key, convey.ShouldEqual, "error")
+		convey.So(Err(err).Value(), convey.ShouldEqual, err)
+	})
+
+	convey.Convey("Fields should be encoded to JSON in order", t, func() {
+		raw, err := json.Marshal(Fields{String("b", "x"), Int("a", 1)})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(string(raw), convey.ShouldEqual, `{"b":"x","a":1}`)
+	})
+
+	convey.Convey("Given a logger", t, func() {
+		mw := &memoryWriter{}
+		l := NewRuntime(mw).New("api")
+
+		convey.Convey("It should accept fields along with attributes", func() {
+			l.Info("{user} has {count:03d} items in {}", "cart", String("user", "alice"), Attrs{"count": 7, "a": 1}, Int("count", 9))
+			log := mw.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "alice has 009 items in cart")
+			convey.So(log.Fields, convey.ShouldResemble, Fields{Any("0", "cart"), String("user", "alice"), Any("a", 1), Int("count", 9)})
+			convey.So(log.DisplayedFields, convey.ShouldResemble, Fields{Any("a", 1)})
+			convey.So(*log.Attrs, convey.ShouldResemble, Attrs{"0": "cart", "user": "alice", "count": 9, "a": 1})
+			convey.So(*log.DisplayedAttrs, convey.ShouldResemble, Attrs{"a": 1})
+		})
+
+		convey.Convey("Attributes of sub-loggers and contexts should not override given ones", func() {
+			err := errors.New("oops")
+			ctx := ContextWithAttrs(context.Background(), Attrs{"request_id": "r1", "user": "ctx"})
+			l.WithAttrs(Attrs{"user": "default", "shard": 1}).ErrorCtx(ctx, "failed", err, String("user", "given"))
+
+			log := mw.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "failed: oops")
+			convey.So(log.Err, convey.ShouldNotBeNil)
+			convey.So(*log.Attrs, convey.ShouldResemble, Attrs{"user": "given", "shard": 1, "request_id": "r1"})
+		})
+	})
+}
+
+// discardWriter drops every log. Like the writers of the package, it reads Log.Fields only.
+type discardWriter struct{}
+
+func (discardWriter) Init()       {}
+func (discardWriter) Write(*Log)  {}
+func (discardWriter) fieldsOnly() {}
+
+func BenchmarkAttrs(b *testing.B) {
+	l := NewRuntime(discardWriter{}).New("api")
+	method, path, status := "GET", "/api/v1/users", 404
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("{method} {path} requested", Attrs{
+			"method":  method,
+			"path":    path,
+			"status":  status,
+			"elapsed": time.Duration(i),
+		})
+	}
+}
+
+func BenchmarkFields(b *testing.B) {
+	l := NewRuntime(discardWriter{}).New("api")
+	method, path, status := "GET", "/api/v1/users", 404
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("{method} {path} requested", Fields{
+			String("method", method),
+			String("path", path),
+			Int("status", status),
+			Duration("elapsed", time.Duration(i)),
+		})
+	}
+}
+
+func BenchmarkAttrsWithDefaults(b *testing.B) {
+	l := NewRuntime(discardWriter{}).New("api").WithAttrs(Attrs{"service": "users", "version": 3})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request handled", Attrs{"status": 404, "elapsed": time.Duration(i)})
+	}
+}
+
+func BenchmarkFieldsWithDefaults(b *testing.B) {
+	l := NewRuntime(discardWriter{}).New("api").WithAttrs(Attrs{"service": "users", "version": 3})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request handled", Int("status", 404), Duration("elapsed", time.Duration(i)))
+	}
+}
+
+func TestGroups(t *testing.T) {
+	convey.Convey("Given a logger", t, func() {
+		mw := &memoryWriter{}
+		l := NewRuntime(mw).New("api")
+		sw := &StandardWriter{}
+
+		convey.Convey("Nested Attrs should be groups", func() {
+			l.Info("HTTP {http.status}", Attrs{"http": Attrs{"method": "GET", "status": 200}, "user": "alice"})
+
+			log := mw.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "HTTP 200")
+			convey.So(*log.Attrs, convey.ShouldResemble, Attrs{"http": Attrs{"method": "GET", "status": 200}, "user": "alice"})
+			convey.So(log.Fields.Flatten(), convey.ShouldResemble, Fields{Any("http.method", "GET"), Any("http.status", 200), Any("user", "alice")})
+			convey.So(sw.PrettyAttrs(log), convey.ShouldEqual, " http.method=GET user=alice")
+		})
+
+		convey.Convey("WithGroup should nest attributes given to it", func() {
+			ctx := ContextWithAttrs(context.Background(), Attrs{"request_id": "r1"})
+			l.WithAttrs(Attrs{"service": "users"}).
+				WithGroup("http").
+				WithAttrs(Attrs{"method": "GET"}).
+				InfoCtx(ctx, "{} responded", "/users", Int("status", 200), Attrs{"http": Attrs{"ignored": true}})
+
+			log := mw.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "/users responded")
+			convey.So(sw.PrettyAttrs(log), convey.ShouldEqual, " http.status=200 http.http.ignored=true http.method=GET service=users request_id=r1")
+		})
+
+		convey.Convey("Groups with the same key should be merged", func() {
+			l.WithAttrs(Attrs{"http": Attrs{"method": "GET", "status": 0}}).Info("done", Group("http", Int("status", 200)))
+
+			convey.So(sw.PrettyAttrs(mw.Logs()[0]), convey.ShouldEqual, " http.status=200 http.method=GET")
+		})
+	})
+
+	convey.Convey("Given a JSON writer", t, func() {
+		buf := new(bytes.Buffer)
+		jw := &JSONWriter{Target: buf, FieldNames: JSONFieldNames{Message: "msg"}}
+		log := &Log{Level: Info, Message: "hi", Fields: Fields{Group("http", String("method", "GET"), Int("status", 200))}}
+
+		convey.Convey("It should write groups as nested objects", func() {
+			convey.So(string(jw.Format(log)), convey.ShouldEqual, `{"msg":"hi","http":{"method":"GET","status":200}}`+"\n")
+		})
+
+		convey.Convey("It should write groups with dotted keys if flattened", func() {
+			jw.FlattenGroups = true
+			convey.So(string(jw.Format(log)), convey.ShouldEqual, `{"msg":"hi","http.method":"GET","http.status":200}`+"\n")
+		})
+
+		convey.Convey("It should write logs created with Attrs only", func() {
+			log := &Log{Level: Info, Message: "hi", Attrs: &Attrs{"http": Attrs{"status": 200}}}
+			convey.So(string(jw.Format(log)), convey.ShouldEqual, `{"msg":"hi","http":{"status":200}}`+"\n")
+
+			log.DisplayedAttrs = &Attrs{"user": "alice"}
+			convey.So((&StandardWriter{}).PrettyAttrs(log), convey.ShouldEqual, " user=alice")
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelFilter decides the minimum log priority of packages by patterns.
+// A pattern is a package name, or a glob where "*" matches any sequence of characters
+// (e.g. "db.*", "*.cache", "*"). A pattern ending with ".*" also matches the parent itself.
+// If several patterns match, an exact name is preferred, and then the longest pattern.
+// A nil or zero LevelFilter has no pattern, so it only emits fatal logs.
+type LevelFilter struct {
+	// state holds immutable *levelFilterState, replaced on every change.
+	state atomic.Value
+	lock  sync.Mutex
+}
+
+type levelFilterState struct {
+	rules    map[string]LogPriority
+	patterns []string
+
+	// cache holds LogPriority by package name.
+	cache sync.Map
+}
+
+// NewLevelFilter returns a filter with given priorities by pattern.
+func NewLevelFilter(rules map[string]LogPriority) *LevelFilter {
+	f := &LevelFilter{}
+	f.store(rules)
+	return f
+}
+
+// Priority returns the minimum priority of logs emitted from the package.
+// If no pattern matches, only fatal logs are emitted.
+func (f *LevelFilter) Priority(pkg string) LogPriority {
+	return f.load().priority(pkg)
+}
+
+// emptyFilterState is the state of nil and zero filters.
+var emptyFilterState = &levelFilterState{}
+
+func (f *LevelFilter) load() *levelFilterState {
+	if f == nil {
+		return emptyFilterState
+	}
+	if state, ok := f.state.Load().(*levelFilterState); ok {
+		return state
+	}
+	return emptyFilterState
+}
+
+func (state *levelFilterState) priority(pkg string) LogPriority {
+	if len(state.patterns) == 0 {
+		return mutePriority
+	}
+	if priority, ok := state.cache.Load(pkg); ok {
+		return priority.(LogPriority)
+	}
+	priority := LogPriority(mutePriority)
+	if pattern, ok := matchPattern(state.patterns, pkg); ok {
+		priority = state.rules[pattern]
+	}
+	state.cache.Store(pkg, priority)
+	return priority
+}
+
+// Enabled returns whether the log with given package and level is emitted.
+func (f *LevelFilter) Enabled(pkg string, level *LogLevel) bool {
+	return level.Priority >= f.Priority(pkg)
+}
+
+// Rules returns a copy of the priorities by pattern.
+func (f *LevelFilter) Rules() map[string]LogPriority {
+	rules := map[string]LogPriority{}
+	for pattern, priority := range f.load().rules {
+		rules[pattern] = priority
+	}
+	return rules
+}
+
+// Set changes the priority of the pattern.
+func (f *LevelFilter) Set(pattern string, priority LogPriority) {
+	f.update(func(rules map[string]LogPriority) {
+		rules[pattern] = priority
+	})
+}
+
+// Unset removes the pattern.
+func (f *LevelFilter) Unset(pattern string) {
+	f.update(func(rules map[string]LogPriority) {
+		delete(rules, pattern)
+	})
+}
+
+func (f *LevelFilter) update(fn func(rules map[string]LogPriority)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	rules := f.Rules()
+	fn(rules)
+	f.store(rules)
+}
+
+func (f *LevelFilter) store(rules map[string]LogPriority) {
+	state := &levelFilterState{rules: map[string]LogPriority{}}
+	for pattern, priority := range rules {
+		state.rules[pattern] = priority
+		state.patterns = append(state.patterns, pattern)
+	}
+	sortPatterns(state.patterns)
+	f.state.Store(state)
+}
+
+// sortPatterns sorts patterns by precedence.
+func sortPatterns(patterns []string) {
+	sort.Slice(patterns, func(i, j int) bool {
+		a, b := patterns[i], patterns[j]
+		if exactA, exactB := !strings.Contains(a, "*"), !strings.Contains(b, "*"); exactA != exactB {
+			return exactA
+		}
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// matchPattern returns the first pattern matching the name. Patterns must be sorted by sortPatterns.
+func matchPattern(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if globMatch(pattern, name) {
+			return pattern, true
+		}
+		if strings.HasSuffix(pattern, ".*") && globMatch(pattern[:len(pattern)-2], name) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// globMatch reports whether the name matches the pattern, where "*" matches any sequence of characters.
+func globMatch(pattern, name string) bool {
+	star, retry := -1, 0
+	p, n := 0, 0
+	for n < len(name) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, retry = p, n
+			p++
+		case p < len(pattern) && pattern[p] == name[n]:
+			p++
+			n++
+		case star >= 0:
+			retry++
+			p, n = star+1, retry
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestLevelFilter(t *testing.T) {
+	convey.Convey("Given a filter with hierarchical patterns", t, func() {
+		f := NewLevelFilter(parsePackageSettings("*@info,db.*@debug,db.postgres@error,*.cache@mute", Verbose.Priority))
+
+		convey.Convey("It should prefer exact names and longer patterns", func() {
+			convey.So(f.Priority("api"), convey.ShouldEqual, Info.Priority)
+			convey.So(f.Priority("db"), convey.ShouldEqual, Debug.Priority)
+			convey.So(f.Priority("db.mysql.pool"), convey.ShouldEqual, Debug.Priority)
+			convey.So(f.Priority("db.postgres"), convey.ShouldEqual, Error.Priority)
+			convey.So(f.Priority("api.cache"), convey.ShouldEqual, Fatal.Priority)
+			convey.So(f.Priority("db.cache"), convey.ShouldEqual, Fatal.Priority)
+		})
+
+		convey.Convey("It should mute packages matching no pattern", func() {
+			f := NewLevelFilter(parsePackageSettings("db.*", Verbose.Priority))
+			convey.So(f.Enabled("api", Error), convey.ShouldBeFalse)
+			convey.So(f.Enabled("db.postgres", Verbose), convey.ShouldBeTrue)
+		})
+	})
+
+	convey.Convey("Nil and zero filters should only emit fatal logs", t, func() {
+		var nilFilter *LevelFilter
+		convey.So(nilFilter.Enabled("api", Error), convey.ShouldBeFalse)
+		convey.So(nilFilter.Enabled("api", Fatal), convey.ShouldBeTrue)
+		convey.So(nilFilter.Rules(), convey.ShouldBeEmpty)
+
+		zero := &LevelFilter{}
+		convey.So(zero.Enabled("api", Error), convey.ShouldBeFalse)
+		zero.Set("api", Info.Priority)
+		convey.So(zero.Enabled("api", Info), convey.ShouldBeTrue)
+
+		convey.So((&StandardWriter{}).IsEnabled("api", Error), convey.ShouldBeFalse)
+		convey.So((&JSONWriter{}).IsEnabled("api", Error), convey.ShouldBeFalse)
+	})
+
+	convey.Convey("Deprecated verbosities should be used without a filter", t, func() {
+		sw := &StandardWriter{Verbosities: map[string]LogPriority{"db": Debug.Priority, "*": Warn.Priority}}
+		convey.So(sw.IsEnabled("db", Debug), convey.ShouldBeTrue)
+		convey.So(sw.IsEnabled("api", Info), convey.ShouldBeFalse)
+		convey.So(sw.IsEnabled("api", Warn), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Named loggers should join names with a dot", t, func() {
+		mw := &memoryWriter{}
+		NewRuntime(mw).New("db").WithAttrs(Attrs{"a": 1}).Named("postgres").Info("hi")
+		convey.So(mw.Logs()[0].Package, convey.ShouldEqual, "db.postgres")
+		convey.So((*mw.Logs()[0].Attrs)["a"], convey.ShouldEqual, 1)
+	})
+}
+
+func TestLevelHandler(t *testing.T) {
+	convey.Convey("Given a runtime with filtered writers", t, func() {
+		first := &JSONWriter{Filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}
+		second := NewDedupWriter(&JSONWriter{Filter: NewLevelFilter(map[string]LogPriority{"*": Warn.Priority})}, time.Second)
+		rt := NewRuntime(first, second)
+		handler := rt.LevelHandler()
+
+		request := func(method, target, body string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+			return rec
+		}
+
+		convey.Convey("GET should list the lowest levels", func() {
+			rec := request(http.MethodGet, "/", "")
+			convey.So(rec.Code, convey.ShouldEqual, http.StatusOK)
+			convey.So(rec.Body.String(), convey.ShouldEqual, `{"*":"INFO"}`+"\n")
+		})
+
+		convey.Convey("PUT should change levels of every writer", func() {
+			rec := request(http.MethodPut, "/", `{"pattern":"db.*","level":"debug"}`)
+			convey.So(rec.Code, convey.ShouldEqual, http.StatusOK)
+			convey.So(first.Filter.Enabled("db.postgres", Debug), convey.ShouldBeTrue)
+			convey.So(second.Unwrap().(*JSONWriter).Filter.Enabled("db.postgres", Debug), convey.ShouldBeTrue)
+
+			request(http.MethodDelete, "/?pattern=db.*", "")
+			convey.So(rt.GetLevels(), convey.ShouldNotContainKey, "db.*")
+		})
+
+		convey.Convey("PUT with TTL should restore the previous levels", func() {
+			request(http.MethodPut, "/", `{"pattern":"*","level":"verbose","ttl":"10ms"}`)
+			convey.So(rt.GetLevels()["*"], convey.ShouldEqual, Verbose)
+
+			time.Sleep(50 * time.Millisecond)
+			convey.So(first.Filter.Priority("api"), convey.ShouldEqual, Info.Priority)
+			convey.So(second.Unwrap().(*JSONWriter).Filter.Priority("api"), convey.ShouldEqual, Warn.Priority)
+		})
+
+		convey.Convey("PUT should reject unknown levels", func() {
+			rec := request(http.MethodPut, "/", `{"pattern":"*","level":"loud"}`)
+			convey.So(rec.Code, convey.ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+// filteredMemoryWriter records logs enabled by its level filter.
+type filteredMemoryWriter struct {
+	memoryWriter
+	filter *LevelFilter
+}
+
+func (fw *filteredMemoryWriter) LevelFilter() *LevelFilter {
+	return fw.filter
+}
+
+func (fw *filteredMemoryWriter) Write(log *Log) {
+	if fw.filter.Enabled(log.Package, log.Level) {
+		fw.memoryWriter.Write(log)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	convey.Convey("Given a runtime with filtered writers", t, func() {
+		info := &filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}
+		warn := &filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Warn.Priority, "db.*": Debug.Priority})}
+		rt := NewRuntime(info, warn)
+
+		convey.Convey("It should enable the lowest level of the writers", func() {
+			convey.So(rt.Enabled("api", Info), convey.ShouldBeTrue)
+			convey.So(rt.Enabled("api", Debug), convey.ShouldBeFalse)
+			convey.So(rt.Enabled("db.postgres", Debug), convey.ShouldBeTrue)
+			convey.So(rt.New("api").Enabled(Debug), convey.ShouldBeFalse)
+			convey.So(rt.New("db").WithAttrs(Attrs{"a": 1}).Enabled(Debug), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("It should follow the level changes", func() {
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeFalse)
+			rt.SetLevel("api", Verbose)
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeTrue)
+			rt.UnsetLevel("api")
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeFalse)
+
+			info.filter.Set("*", Verbose.Priority)
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("It should enable every level if a writer is unfiltered", func() {
+			handle := rt.Hook(&memoryWriter{})
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeTrue)
+			handle.Unhook()
+			convey.So(rt.Enabled("api", Verbose), convey.ShouldBeFalse)
+		})
+
+		convey.Convey("Disabled logs should not be written", func() {
+			l := rt.New("api")
+			l.Debug("hidden")
+			l.VerboseCtx(context.Background(), "hidden")
+			l.Info("shown")
+			convey.So(info.Logs(), convey.ShouldHaveLength, 1)
+			convey.So(info.Logs()[0].Message, convey.ShouldEqual, "shown")
+		})
+	})
+}
+
+func BenchmarkDisabledLog(b *testing.B) {
+	l := NewRuntime(&filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}).New("api")
+	attrs := Attrs{"method": "GET", "path": "/api/v1/users"}
+
+	loggers := []struct {
+		name   string
+		logger Logger
+	}{
+		{"Logger", l},
+		{"WithAttrs", l.WithAttrs(Attrs{"region": "kr"})},
+		{"WithGroup", l.WithGroup("http").WithAttrs(Attrs{"region": "kr"})},
+	}
+	for _, bm := range loggers {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bm.logger.Debug("{method} {path} requested", attrs)
+			}
+		})
+	}
+}
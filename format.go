@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -54,3 +55,31 @@ func colorFor(key string) string {
 func Colored(color string, msg string) string {
 	return color + msg + Reset
 }
+
+// stripColors removes ANSI escape sequences (e.g. colors added by Colored) from the text.
+func stripColors(s string) string {
+	i := strings.IndexByte(s, '\x1b')
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i >= 0 {
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		if len(s) > 0 && s[0] == '[' {
+			// parameter and intermediate bytes, followed by a final byte
+			j := 1
+			for j < len(s) && s[j] >= 0x20 && s[j] <= 0x3f {
+				j++
+			}
+			if j < len(s) && s[j] >= 0x40 && s[j] <= 0x7e {
+				j++
+			}
+			s = s[j:]
+		}
+		i = strings.IndexByte(s, '\x1b')
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,478 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// placeholder is a replacement field of a format string, like "{name!r:>12}".
+type placeholder struct {
+	// field is the field name as written, e.g. "user.Name" or "items[0]".
+	field string
+
+	// key is the attribute key of the field, e.g. "user". Positional fields are keyed by its index.
+	key       string
+	accessors []fieldAccessor
+
+	conversion byte
+	spec       formatSpec
+}
+
+// fieldAccessor is an attribute (".Name") or index ("[0]") access to the value.
+type fieldAccessor struct {
+	name    string
+	isIndex bool
+}
+
+// parsePlaceholder parses the content of a replacement field (without brackets).
+// Empty field names are numbered by autoIndex. It returns false if the content doesn't look like
+// a replacement field (e.g. a JSON body in the message), so it can be printed as is.
+func parsePlaceholder(content string, autoIndex *int) (p placeholder, ok bool) {
+	p.spec = defaultFormatSpec
+	field := content
+	if i := strings.IndexAny(content, "!:"); i >= 0 {
+		field = content[:i]
+		rest := content[i:]
+		if rest[0] == '!' {
+			if len(rest) < 2 {
+				return p, false
+			}
+			p.conversion = rest[1]
+			if p.conversion != 'r' && p.conversion != 's' && p.conversion != 'a' {
+				return p, false
+			}
+			rest = rest[2:]
+		}
+		if len(rest) > 0 {
+			if rest[0] != ':' {
+				return p, false
+			}
+			if p.spec, ok = parseFormatSpec(rest[1:]); !ok {
+				return p, false
+			}
+		}
+	}
+
+	p.field = field
+	rootEnd := strings.IndexAny(field, ".[")
+	if rootEnd < 0 {
+		rootEnd = len(field)
+	}
+	p.key = field[:rootEnd]
+	if strings.ContainsAny(p.key, " \t\n\"'{}]") {
+		return p, false
+	}
+	if p.key == "" {
+		if rootEnd != len(field) {
+			return p, false
+		}
+		p.key = strconv.Itoa(*autoIndex)
+		p.field = p.key
+		*autoIndex++
+	}
+
+	for rest := field[rootEnd:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return p, false
+			}
+			p.accessors = append(p.accessors, fieldAccessor{name: name})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 2 {
+				return p, false
+			}
+			p.accessors = append(p.accessors, fieldAccessor{name: rest[1:end], isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return p, false
+		}
+	}
+	return p, true
+}
+
+// resolve returns the value of the field from attributes, and the key (or the dotted path in groups)
+// of the attribute used.
+// A key containing dots (e.g. "http.status") is looked up as is before accessing attributes.
+func (p placeholder) resolve(src attrSource) (value Field, key string, ok bool) {
+	if len(p.accessors) > 0 {
+		if f, ok := src.lookup(p.field); ok {
+			return f, p.field, true
+		}
+	}
+	f, ok := src.lookup(p.key)
+	if !ok {
+		return Field{}, "", false
+	}
+	if len(p.accessors) == 0 {
+		return f, p.key, true
+	}
+	// fields in groups are used by their dotted paths, so the rest of the group is still displayed
+	key, v := p.field, f.Value()
+	for _, acc := range p.accessors {
+		if _, isGroup := v.(Fields); !isGroup || acc.isIndex {
+			key = p.key
+		}
+		if v, ok = acc.access(v); !ok {
+			return Field{}, "", false
+		}
+	}
+	return Any(p.key, v), key, true
+}
+
+func (acc fieldAccessor) access(value interface{}) (interface{}, bool) {
+	if group, ok := value.(Fields); ok {
+		if acc.isIndex {
+			return nil, false
+		}
+		return group.Get(acc.name)
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		key := reflect.ValueOf(acc.name)
+		if kind := v.Type().Key().Kind(); kind != reflect.String {
+			i, err := strconv.ParseInt(acc.name, 10, 64)
+			if err != nil || kind < reflect.Int || kind > reflect.Int64 {
+				return nil, false
+			}
+			key = reflect.ValueOf(i)
+		}
+		if !key.Type().ConvertibleTo(v.Type().Key()) {
+			return nil, false
+		}
+		elem := v.MapIndex(key.Convert(v.Type().Key()))
+		if !elem.IsValid() {
+			return nil, false
+		}
+		return elem.Interface(), true
+
+	case reflect.Slice, reflect.Array, reflect.String:
+		if !acc.isIndex {
+			return nil, false
+		}
+		i, err := strconv.Atoi(acc.name)
+		if err != nil || i < 0 || i >= v.Len() {
+			return nil, false
+		}
+		return v.Index(i).Interface(), true
+
+	case reflect.Struct:
+		if acc.isIndex {
+			return nil, false
+		}
+		field, ok := v.Type().FieldByName(acc.name)
+		if !ok || field.PkgPath != "" {
+			// unexported
+			return nil, false
+		}
+		return v.FieldByIndex(field.Index).Interface(), true
+	}
+	return nil, false
+}
+
+// render formats the value with the conversion and the format spec.
+func (p placeholder) render(value interface{}) string {
+	switch p.conversion {
+	case 'r':
+		value = strconv.Quote(fmt.Sprintf("%v", value))
+	case 'a':
+		value = strconv.QuoteToASCII(fmt.Sprintf("%v", value))
+	case 's':
+		value = fmt.Sprintf("%v", value)
+	}
+	return p.spec.format(value)
+}
+
+// missing is rendered in place of a field which can't be resolved.
+func (p placeholder) missing() string {
+	return "%!{" + p.field + "}(MISSING)"
+}
+
+// formatSpec is a PEP 3101 format spec: [[fill]align][sign][#][0][width][grouping][.precision][type]
+type formatSpec struct {
+	fill      rune
+	align     byte
+	sign      byte
+	alternate bool
+	width     int
+	grouping  byte
+	precision int
+	verb      byte
+}
+
+// defaultFormatSpec renders values with "%v".
+var defaultFormatSpec = formatSpec{fill: ' ', precision: -1}
+
+func parseFormatSpec(s string) (spec formatSpec, ok bool) {
+	spec = defaultFormatSpec
+
+	if r, size := utf8.DecodeRuneInString(s); size > 0 && size < len(s) && isAlign(s[size]) {
+		spec.fill, spec.align = r, s[size]
+		s = s[size+1:]
+	} else if len(s) > 0 && isAlign(s[0]) {
+		spec.align = s[0]
+		s = s[1:]
+	}
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-' || s[0] == ' ') {
+		spec.sign = s[0]
+		s = s[1:]
+	}
+	if len(s) > 0 && s[0] == '#' {
+		spec.alternate = true
+		s = s[1:]
+	}
+	if len(s) > 0 && s[0] == '0' {
+		if spec.align == 0 {
+			spec.fill, spec.align = '0', '='
+		}
+		s = s[1:]
+	}
+	spec.width, s = parseDigits(s)
+	if len(s) > 0 && (s[0] == ',' || s[0] == '_') {
+		spec.grouping = s[0]
+		s = s[1:]
+	}
+	if len(s) > 0 && s[0] == '.' {
+		if len(s) < 2 || s[1] < '0' || s[1] > '9' {
+			return spec, false
+		}
+		spec.precision, s = parseDigits(s[1:])
+	}
+	if len(s) > 0 {
+		if !strings.ContainsRune("sdboxXceEfFgG%", rune(s[0])) || len(s) > 1 {
+			return spec, false
+		}
+		spec.verb = s[0]
+	}
+	return spec, true
+}
+
+func isAlign(c byte) bool {
+	return c == '<' || c == '>' || c == '^' || c == '='
+}
+
+func parseDigits(s string) (n int, rest string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		n = n*10 + int(s[i]-'0')
+		i++
+	}
+	return n, s[i:]
+}
+
+// format renders the value. Values not matching a numeric type (e.g. a string for "d")
+// are rendered with "%v", still aligned and padded.
+func (spec formatSpec) format(value interface{}) string {
+	if spec == defaultFormatSpec {
+		return fmt.Sprintf("%v", value)
+	}
+	sign, body, numeric := "", "", false
+
+	v := reflect.ValueOf(value)
+	switch _, isStringer := value.(fmt.Stringer); {
+	case spec.verb == 's' || (spec.verb == 0 && isStringer):
+		// use the string representation (e.g. time.Duration)
+	case spec.verb == 'c' && v.Kind() >= reflect.Int && v.Kind() <= reflect.Uintptr:
+		body = string(rune(v.Convert(reflect.TypeOf(rune(0))).Int()))
+		return spec.pad("", body, false)
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		sign, body, numeric = spec.formatInt(v.Int() < 0, uint64(abs(v.Int())))
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		sign, body, numeric = spec.formatInt(false, v.Uint())
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		sign, body, numeric = spec.formatFloat(v.Float())
+	}
+	if !numeric {
+		body = fmt.Sprintf("%v", value)
+		if spec.precision >= 0 && utf8.RuneCountInString(body) > spec.precision {
+			body = string([]rune(body)[:spec.precision])
+		}
+	}
+	return spec.pad(sign, body, numeric)
+}
+
+func abs(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func (spec formatSpec) formatInt(negative bool, n uint64) (sign, body string, ok bool) {
+	switch spec.verb {
+	case 0, 'd':
+		body = strconv.FormatUint(n, 10)
+		body = spec.group(body, 3)
+	case 'b', 'o', 'x', 'X':
+		base := map[byte]int{'b': 2, 'o': 8, 'x': 16, 'X': 16}[spec.verb]
+		body = strconv.FormatUint(n, base)
+		body = spec.group(body, 4)
+		if spec.verb == 'X' {
+			body = strings.ToUpper(body)
+		}
+		if spec.alternate {
+			body = "0" + string(spec.verb) + body
+		}
+	case 'e', 'E', 'f', 'F', 'g', 'G', '%':
+		f := float64(n)
+		if negative {
+			f = -f
+		}
+		return spec.formatFloat(f)
+	default:
+		return "", "", false
+	}
+	return spec.signOf(negative), body, true
+}
+
+func (spec formatSpec) formatFloat(f float64) (sign, body string, ok bool) {
+	negative := math.Signbit(f)
+	f = math.Abs(f)
+	precision := spec.precision
+
+	switch spec.verb {
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		if precision < 0 {
+			precision = 6
+		}
+		verb := spec.verb
+		if verb == 'F' {
+			verb = 'f'
+		}
+		body = strconv.FormatFloat(f, verb, precision, 64)
+	case '%':
+		if precision < 0 {
+			precision = 6
+		}
+		body = strconv.FormatFloat(f*100, 'f', precision, 64) + "%"
+	case 0:
+		switch {
+		case precision >= 0:
+			body = strconv.FormatFloat(f, 'g', precision, 64)
+		case f == 0 || (f >= 1e-4 && f < 1e16):
+			// as Python's repr, which uses exponents only for very small or large magnitudes
+			body = strconv.FormatFloat(f, 'f', -1, 64)
+			if !strings.ContainsRune(body, '.') {
+				body += ".0"
+			}
+		default:
+			body = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	default:
+		return "", "", false
+	}
+
+	// group the integer part only
+	intEnd := strings.IndexFunc(body, func(r rune) bool { return r < '0' || r > '9' })
+	if intEnd < 0 {
+		intEnd = len(body)
+	}
+	body = spec.group(body[:intEnd], 3) + body[intEnd:]
+	return spec.signOf(negative), body, true
+}
+
+func (spec formatSpec) signOf(negative bool) string {
+	switch {
+	case negative:
+		return "-"
+	case spec.sign == '+':
+		return "+"
+	case spec.sign == ' ':
+		return " "
+	}
+	return ""
+}
+
+// group inserts the grouping separator to digits every n digits from the right.
+func (spec formatSpec) group(digits string, n int) string {
+	if spec.grouping == 0 || len(digits) <= n {
+		return digits
+	}
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%n == 0 {
+			b.WriteByte(spec.grouping)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (spec formatSpec) pad(sign, body string, numeric bool) string {
+	padding := spec.width - utf8.RuneCountInString(sign) - utf8.RuneCountInString(body)
+	if padding <= 0 {
+		return sign + body
+	}
+	align := spec.align
+	if align == 0 {
+		align = '<'
+		if numeric {
+			align = '>'
+		}
+	}
+	fill := strings.Repeat(string(spec.fill), padding)
+
+	switch align {
+	case '>':
+		return fill + sign + body
+	case '^':
+		half := strings.Repeat(string(spec.fill), padding/2)
+		return half + sign + body + strings.Repeat(string(spec.fill), padding-padding/2)
+	case '=':
+		if numeric && spec.fill == '0' && spec.grouping != 0 {
+			return sign + spec.zeroFillGrouped(body, spec.width-utf8.RuneCountInString(sign))
+		}
+		if numeric {
+			return sign + fill + body
+		}
+		return fill + sign + body
+	}
+	return sign + body + fill
+}
+
+// zeroFillGrouped pads the grouped integer part of the body with zeros to the width,
+// grouping the zeros as well. As Python, a zero is added instead of a leading separator.
+func (spec formatSpec) zeroFillGrouped(body string, width int) string {
+	prefix, n := "", 3
+	switch spec.verb {
+	case 'b', 'o', 'x', 'X':
+		n = 4
+		if spec.alternate {
+			prefix, body = body[:2], body[2:]
+		}
+	}
+	intEnd := strings.IndexFunc(body, func(r rune) bool {
+		isDigit := (r >= '0' && r <= '9') || (n == 4 && strings.ContainsRune("abcdefABCDEF", r))
+		return !isDigit && r != rune(spec.grouping)
+	})
+	if intEnd < 0 {
+		intEnd = len(body)
+	}
+	digits, rest := strings.Replace(body[:intEnd], string(spec.grouping), "", -1), body[intEnd:]
+	width -= len(prefix) + len(rest)
+	grouped := spec.group(digits, n)
+	for len(grouped) < width {
+		digits = "0" + digits
+		grouped = spec.group(digits, n)
+	}
+	return prefix + grouped + rest
+}
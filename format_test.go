@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+type formatTestUser struct {
+	Name  string
+	Roles []string
+}
+
+func TestFormat(t *testing.T) {
+	convey.Convey("Format should substitute replacement fields", t, func() {
+		attrs := Attrs{
+			"name":   "alice",
+			"amount": 1234.5678,
+			"id":     42,
+			"count":  1234567,
+			"neg":    -42,
+			"big":    -1234567.5,
+			"whole":  2.0,
+			"addr":   "1 Main St",
+			"user":   &formatTestUser{Name: "bob", Roles: []string{"admin", "dev"}},
+			"items":  []int{7, 8, 9},
+			"ratio":  0.256,
+			"wait":   1500 * time.Millisecond,
+			"0":      "first",
+			"1":      2,
+		}
+		cases := map[string]string{
+			"Hello {name}":                   "Hello alice",
+			"{} and {}":                      "first and 2",
+			"{1} then {0}":                   "2 then first",
+			"{amount:.2f}":                   "1234.57",
+			"{amount:,.1f}":                  "1,234.6",
+			"[{name:>8}]":                    "[   alice]",
+			"[{name:<8}]":                    "[alice   ]",
+			"[{name:*^9}]":                   "[**alice**]",
+			"{id:08d}":                       "00000042",
+			"{neg:06d}":                      "-00042",
+			"{id:+d}":                        "+42",
+			"{id:#x}":                        "0x2a",
+			"{id:b}":                         "101010",
+			"{count:,}":                      "1,234,567",
+			"{count:_}":                      "1_234_567",
+			"{big:,}":                        "-1,234,567.5",
+			"{whole:>5}":                     "  2.0",
+			"{count:012,d}":                  "0,001,234,567",
+			"{neg:08,}":                      "-000,042",
+			"{count:#012_x}":                 "0x0_0012_d687",
+			"{amount:010,.1f}":               "0,001,234.6",
+			"{ratio:.1%}":                    "25.6%",
+			"{amount:e}":                     "1.234568e+03",
+			"{addr!r}":                       `"1 Main St"`,
+			"{name:.3}":                      "ali",
+			"{user.Name} is {user.Roles[0]}": "bob is admin",
+			"{items[2]}":                     "9",
+			"{wait}":                         "1.5s",
+			"{wait:>6}":                      "  1.5s",
+			"{{name}} is {name}":             "{name} is alice",
+			"{unknown}":                      "%!{unknown}(MISSING)",
+			"{user.Email}":                   "%!{user.Email}(MISSING)",
+			`body: {"a": 1}`:                 `body: {"a": 1}`,
+		}
+		for format, expected := range cases {
+			formatted, _ := Format(format, attrs)
+			convey.So(formatted, convey.ShouldEqual, expected)
+		}
+	})
+
+	convey.Convey("Format should purge used attributes", t, func() {
+		_, purged := Format("{name} {user.Name} {}", Attrs{"name": "a", "user": formatTestUser{Name: "b"}, "0": 1, "1": 2, "to": "c"})
+		convey.So(purged, convey.ShouldResemble, Attrs{"1": 2, "to": "c"})
+	})
+}
+
+func TestTemplateCache(t *testing.T) {
+	convey.Convey("Templates should be compiled once per format string", t, func() {
+		format := "cached {name} template"
+		first := lookupTemplate(format)
+		convey.So(lookupTemplate(format), convey.ShouldEqual, first)
+
+		formatted, purged := Format(format, Attrs{"name": "a", "other": 1})
+		convey.So(formatted, convey.ShouldEqual, "cached a template")
+		convey.So(purged, convey.ShouldResemble, Attrs{"other": 1})
+	})
+
+	convey.Convey("Static templates should be rendered as is", t, func() {
+		formatted, purged := Format("no {{fields}} here", Attrs{"0": 1})
+		convey.So(formatted, convey.ShouldEqual, "no {fields} here")
+		convey.So(purged, convey.ShouldResemble, Attrs{"0": 1})
+	})
+
+	convey.Convey("The least recently used template should be evicted", t, func() {
+		cache := newTemplateLRU(2)
+		cache.add("a", compileTemplate("a"))
+		cache.add("b", compileTemplate("b"))
+		cache.get("a")
+		cache.add("c", compileTemplate("c"))
+
+		_, ok := cache.get("b")
+		convey.So(ok, convey.ShouldBeFalse)
+		_, ok = cache.get("a")
+		convey.So(ok, convey.ShouldBeTrue)
+		_, ok = cache.get("c")
+		convey.So(ok, convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Dynamic text should not be cached as templates", t, func() {
+		mw := &memoryWriter{}
+		l := NewRuntime(mw).New("test")
+		l.Error("failed", errors.New("bad {input} 1"))
+		l.Wtf(errors.New("bad {input} 2"))
+		w := l.WithAttrs(Attrs{"a": 1}).Writer(Info)
+		_, _ = w.Write([]byte("bad {input} 3\n"))
+
+		logs := mw.Logs()
+		convey.So(logs, convey.ShouldHaveLength, 3)
+		convey.So(logs[0].Message, convey.ShouldEqual, "failed: bad {input} 1")
+		convey.So(logs[1].Message, convey.ShouldEqual, Colored(Red, "bad {input} 2"))
+		convey.So(logs[2].Message, convey.ShouldEqual, "bad {input} 3")
+		templateCache.lock.Lock()
+		defer templateCache.lock.Unlock()
+		for format := range templateCache.entries {
+			convey.So(format, convey.ShouldNotContainSubstring, "bad {")
+		}
+	})
+}
+
+// legacyFormat is the implementation replaced by pre-compiled templates, kept for benchmarks.
+func legacyFormat(format string, attrs Attrs) (formatted string, purged Attrs) {
+	formatted = format
+	purged = Attrs{}
+
+	for key, value := range attrs {
+		placeholder := "{" + key + "}"
+		if strings.Contains(format, placeholder) && !strings.Contains(format, "{{"+key+"}}") {
+			valueStr := fmt.Sprintf("%v", value)
+			formatted = strings.Replace(formatted, placeholder, valueStr, -1)
+		} else {
+			purged[key] = value
+		}
+	}
+
+	if _, ok := purged["0"]; ok {
+		index := 0
+		for strings.Contains(formatted, "{}") {
+			key := strconv.Itoa(index)
+			value := fmt.Sprintf("%v", purged[key])
+			formatted = strings.Replace(formatted, "{}", value, 1)
+
+			delete(purged, key)
+			index++
+		}
+	}
+
+	escape := strings.NewReplacer("{{", "{", "}}", "}")
+	formatted = escape.Replace(formatted)
+	return
+}
+
+var benchmarkFormats = []struct {
+	name   string
+	format string
+	attrs  Attrs
+}{
+	{"Static", "request handled", Attrs{"foo": 123, "bar": true}},
+	{"Positional", "foobar {}", Attrs{"0": "yoyo", "foo": 123, "bar": true}},
+	{"Keyed", "{method} {path} responded {status} in {elapsed}", Attrs{
+		"method": "GET", "path": "/api/v1/users", "status": 200, "elapsed": 1500 * time.Microsecond, "user": "alice",
+	}},
+}
+
+func BenchmarkFormat(b *testing.B) {
+	for _, bm := range benchmarkFormats {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Format(bm.format, bm.attrs)
+			}
+		})
+	}
+}
+
+// BenchmarkFormatUncached measures parsing and rendering of templates, without purging attributes.
+func BenchmarkFormatUncached(b *testing.B) {
+	for _, bm := range benchmarkFormats {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				compileTemplate(bm.format).render(attrSource{attrs: bm.attrs}, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkLegacyFormat(b *testing.B) {
+	for _, bm := range benchmarkFormats {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				legacyFormat(bm.format, bm.attrs)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/airbloc/logger
 
-go 1.13
+go 1.14
 
 require (
 	github.com/azer/is-terminal v1.0.0
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// TimeFormatUnixNano makes JSONWriter emit timestamps as Unix nanoseconds instead of a formatted string.
+	TimeFormatUnixNano = "unixnano"
+)
+
+// JSONFieldNames customizes keys of the built-in fields in the JSON output.
+type JSONFieldNames struct {
+	Time     string
+	Package  string
+	Level    string
+	Message  string
+	Elapsed  string
+	Caller   string
+	Function string
+	Error    string
+}
+
+// DefaultJSONFieldNames is used by NewJSONOutput.
+var DefaultJSONFieldNames = JSONFieldNames{
+	Time:     "time",
+	Package:  "package",
+	Level:    "level",
+	Message:  "msg",
+	Elapsed:  "elapsed",
+	Caller:   "caller",
+	Function: "func",
+	Error:    "error",
+}
+
+// NewJSONOutput returns a writer emitting one JSON object per line,
+// filtered by the same level and package settings as NewStandardOutput.
+func NewJSONOutput(target io.Writer, levelSettings, filterSettings string) *JSONWriter {
+	return &JSONWriter{
+		Target:     target,
+		Filter:     parseOutputSettings(levelSettings, filterSettings),
+		FieldNames: DefaultJSONFieldNames,
+		TimeFormat: time.RFC3339Nano,
+	}
+}
+
+type JSONWriter struct {
+	Target     io.Writer
+	Filter     *LevelFilter
+	FieldNames JSONFieldNames
+
+	// TimeFormat is a layout for time.Format, or TimeFormatUnixNano.
+	TimeFormat string
+
+	// FlattenGroups writes attributes in groups with dotted keys (e.g. "http.status"),
+	// instead of nested objects.
+	FlattenGroups bool
+
+	mu sync.Mutex
+}
+
+func (jw *JSONWriter) Init() {}
+
+func (jw *JSONWriter) fieldsOnly() {}
+
+func (jw *JSONWriter) LevelFilter() *LevelFilter {
+	return jw.Filter
+}
+
+func (jw *JSONWriter) Write(log *Log) {
+	if !jw.IsEnabled(log.Package, log.Level) {
+		return
+	}
+	line := jw.Format(log)
+
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+	_, _ = jw.Target.Write(line)
+}
+
+func (jw *JSONWriter) IsEnabled(logger string, level *LogLevel) bool {
+	return jw.Filter.Enabled(logger, level)
+}
+
+// Format encodes the log into a newline-terminated JSON object.
+// Attributes are flattened to the top level, sorted by key. An attribute whose key
+// collides with one of the built-in fields is prefixed with "attrs.".
+func (jw *JSONWriter) Format(log *Log) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+
+	reserved := map[string]bool{}
+	field := func(key string, value interface{}) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		writeJSONValue(buf, key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, value)
+	}
+	builtin := func(key string, value interface{}) {
+		if key == "" {
+			return
+		}
+		reserved[key] = true
+		field(key, value)
+	}
+
+	builtin(jw.FieldNames.Time, jw.formatTime(log.Time))
+	builtin(jw.FieldNames.Level, log.Level.String())
+	builtin(jw.FieldNames.Package, log.Package)
+	// messages may be colored for the console (e.g. by Wtf)
+	builtin(jw.FieldNames.Message, stripColors(log.Message))
+	if log.Level.Priority == Timer.Priority {
+		if encoded, ok := encodeDuration(time.Duration(log.ElapsedNano)); ok {
+			builtin(jw.FieldNames.Elapsed, encoded)
+		} else {
+			builtin(jw.FieldNames.Elapsed, log.ElapsedNano)
+		}
+	}
+	if log.Err != nil {
+		builtin(jw.FieldNames.Error, log.Err)
+	}
+	if log.File != "" {
+		builtin(jw.FieldNames.Caller, log.Caller())
+		builtin(jw.FieldNames.Function, log.Function)
+	}
+
+	var attrs Fields
+	if jw.FlattenGroups {
+		attrs = log.fields().Flatten()
+	} else {
+		attrs = append(attrs, log.fields()...)
+	}
+	sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	for _, f := range attrs {
+		name := f.Key
+		if reserved[name] {
+			name = "attrs." + name
+		}
+		field(name, f.Value())
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (jw *JSONWriter) formatTime(t int64) interface{} {
+	if jw.TimeFormat == TimeFormatUnixNano {
+		return t
+	}
+	layout := jw.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return time.Unix(0, t).Format(layout)
+}
+
+// writeJSONValue encodes given value, falling back to its string representation
+// if the value can't be marshaled (e.g. channels, functions or marshaler errors).
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		raw, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	buf.Write(raw)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestJSONWriter(t *testing.T) {
+	convey.Convey("Given a JSON writer", t, func() {
+		buf := new(bytes.Buffer)
+		jw := &JSONWriter{
+			Target:     buf,
+			Filter:     NewLevelFilter(map[string]LogPriority{"*": 0}),
+			FieldNames: DefaultJSONFieldNames,
+			TimeFormat: TimeFormatUnixNano,
+		}
+
+		convey.Convey("It should flatten attributes to the top level", func() {
+			jw.Write(&Log{
+				Package: "mail",
+				Level:   Info,
+				Message: "Sending an e-mail",
+				Time:    42,
+				Attrs:   &Attrs{"to": "qux@corge.com", "msg": "collision"},
+			})
+
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["time"], convey.ShouldEqual, 42)
+			convey.So(out["level"], convey.ShouldEqual, "INFO")
+			convey.So(out["package"], convey.ShouldEqual, "mail")
+			convey.So(out["msg"], convey.ShouldEqual, "Sending an e-mail")
+			convey.So(out["to"], convey.ShouldEqual, "qux@corge.com")
+			convey.So(out["attrs.msg"], convey.ShouldEqual, "collision")
+		})
+
+		convey.Convey("It should use custom field names", func() {
+			jw.FieldNames.Message = "message"
+			jw.FieldNames.Time = ""
+			jw.Write(&Log{Package: "app", Level: Timer, Message: "done", ElapsedNano: 1000})
+
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["message"], convey.ShouldEqual, "done")
+			convey.So(out["elapsed"], convey.ShouldEqual, 1000)
+			convey.So(out, convey.ShouldNotContainKey, "time")
+		})
+
+		convey.Convey("It should not write filtered logs", func() {
+			jw.Filter = NewLevelFilter(map[string]LogPriority{"*": Error.Priority})
+			jw.Write(&Log{Package: "app", Level: Info, Message: "hidden"})
+			convey.So(buf.Len(), convey.ShouldEqual, 0)
+		})
+
+		convey.Convey("It should not write colors of messages", func() {
+			NewRuntime(jw).New("app").Wtf("boom", Attrs{"color": Red})
+
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["msg"], convey.ShouldEqual, "boom")
+			convey.So(out["color"], convey.ShouldEqual, Red)
+		})
+	})
+}
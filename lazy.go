@@ -0,0 +1,6 @@
+package logger
+
+// Lazy is an attribute value evaluated only when the log is emitted,
+// e.g. logger.Lazy(func() interface{} { return expensiveDump(req) }).
+// It's evaluated once, before formatting the message, and writers receive the result.
+type Lazy func() interface{}
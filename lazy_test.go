@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestLazy(t *testing.T) {
+	convey.Convey("Given lazy attributes", t, func() {
+		first := &filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}
+		second := &memoryWriter{}
+		l := NewRuntime(first, second).New("api")
+
+		calls := 0
+		attrs := Attrs{"dump": Lazy(func() interface{} {
+			calls++
+			return "expensive"
+		})}
+
+		convey.Convey("It should be evaluated once for all writers", func() {
+			l.Info("dumped {dump}", attrs)
+			convey.So(calls, convey.ShouldEqual, 1)
+			convey.So(first.Logs()[0].Message, convey.ShouldEqual, "dumped expensive")
+			convey.So((*second.Logs()[0].Attrs)["dump"], convey.ShouldEqual, "expensive")
+			convey.So(attrs["dump"], convey.ShouldHaveSameTypeAs, Lazy(nil))
+		})
+
+		convey.Convey("It should not be evaluated if the log is disabled", func() {
+			NewRuntime(first).New("api").Verbose("dumped {dump}", attrs)
+			convey.So(calls, convey.ShouldEqual, 0)
+		})
+
+		convey.Convey("Positional arguments and timers should be evaluated", func() {
+			l.Info("dumped {}", attrs["dump"])
+			l.Timer().End("dumped {dump}", attrs)
+			convey.So(calls, convey.ShouldEqual, 2)
+			convey.So(second.Logs()[0].Message, convey.ShouldEqual, "dumped expensive")
+			convey.So(second.Logs()[1].Message, convey.ShouldEqual, "dumped expensive")
+		})
+	})
+}
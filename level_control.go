@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// SetLevel changes the minimum level of packages matching the pattern (e.g. "db.*") on every writer.
+func (runtime *Runtime) SetLevel(pattern string, level *LogLevel) {
+	runtime.swapLevel(pattern, level)
+}
+
+// UnsetLevel removes the pattern from every writer.
+func (runtime *Runtime) UnsetLevel(pattern string) {
+	runtime.swapLevel(pattern, nil)
+}
+
+// GetLevels returns the minimum levels by pattern. If writers have different levels
+// for a pattern, the lowest one is returned.
+func (runtime *Runtime) GetLevels() map[string]*LogLevel {
+	priorities := map[string]LogPriority{}
+	for _, f := range runtime.filters() {
+		for pattern, priority := range f.Rules() {
+			if current, ok := priorities[pattern]; !ok || priority < current {
+				priorities[pattern] = priority
+			}
+		}
+	}
+	levels := make(map[string]*LogLevel, len(priorities))
+	for pattern, priority := range priorities {
+		levels[pattern] = levelOfPriority(priority)
+	}
+	return levels
+}
+
+// swapLevel changes the level of the pattern on every writer, or removes the pattern if the level is nil.
+// It returns a function restoring the previous levels.
+func (runtime *Runtime) swapLevel(pattern string, level *LogLevel) (restore func()) {
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+
+	type previousRule struct {
+		filter   *LevelFilter
+		priority LogPriority
+		exists   bool
+	}
+	var previous []previousRule
+
+	for _, f := range runtime.filters() {
+		priority, exists := f.Rules()[pattern]
+		previous = append(previous, previousRule{filter: f, priority: priority, exists: exists})
+		if level == nil {
+			f.Unset(pattern)
+		} else {
+			f.Set(pattern, level.Priority)
+		}
+	}
+	return func() {
+		runtime.lock.Lock()
+		defer runtime.lock.Unlock()
+
+		for _, p := range previous {
+			if p.exists {
+				p.filter.Set(pattern, p.priority)
+			} else {
+				p.filter.Unset(pattern)
+			}
+		}
+	}
+}
+
+// filters returns level filters of the writers, including wrapped ones.
+func (runtime *Runtime) filters() (filters []*LevelFilter) {
+	for _, e := range runtime.entries() {
+		if f := writerFilter(e.writer); f != nil {
+			filters = append(filters, f)
+		}
+	}
+	return
+}
+
+// writerFilter returns the level filter of the writer or the writer wrapped by it, or nil if it's unfiltered.
+func writerFilter(w OutputWriter) *LevelFilter {
+	for w != nil {
+		if fw, ok := w.(FilteredWriter); ok && fw.LevelFilter() != nil {
+			return fw.LevelFilter()
+		}
+		ww, ok := w.(WrappingWriter)
+		if !ok {
+			return nil
+		}
+		w = ww.Unwrap()
+	}
+	return nil
+}
+
+// writerEnabler returns the writer or the writer wrapped by it which decides by itself which logs
+// it emits, or nil if there is none.
+func writerEnabler(w OutputWriter) EnablingWriter {
+	for w != nil {
+		if ew, ok := w.(EnablingWriter); ok {
+			return ew
+		}
+		ww, ok := w.(WrappingWriter)
+		if !ok {
+			return nil
+		}
+		w = ww.Unwrap()
+	}
+	return nil
+}
+
+// Enabled returns whether any writer of the runtime emits the log with given package and level.
+// Loggers check it before formatting logs, so disabled logs cost almost nothing.
+func (runtime *Runtime) Enabled(pkg string, level *LogLevel) bool {
+	levels := runtime.enabledLevels()
+	if levels.unfiltered || level.Priority >= levels.priority(pkg) {
+		return true
+	}
+	for _, ew := range levels.enablers {
+		if ew.IsEnabled(pkg, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledLevels holds the minimum priority emitted by any writer, by package.
+type enabledLevels struct {
+	writersVersion uint64
+
+	// filters are the level filters of writers, and states are their states when the levels are built.
+	filters []*LevelFilter
+	states  []*levelFilterState
+
+	// enablers are writers without a level filter deciding by themselves, asked on every check.
+	enablers []EnablingWriter
+
+	// unfiltered is set if any writer emits logs of every level.
+	unfiltered bool
+
+	// cache holds LogPriority by package name.
+	cache sync.Map
+}
+
+// enabledLevels returns the cached levels, or rebuilds them if writers or their level filters are changed.
+func (runtime *Runtime) enabledLevels() *enabledLevels {
+	levels, _ := runtime.levels.Load().(*enabledLevels)
+	if levels != nil && levels.valid(runtime) {
+		return levels
+	}
+
+	levels = &enabledLevels{writersVersion: atomic.LoadUint64(&runtime.writersVersion)}
+	for _, e := range runtime.entries() {
+		f := writerFilter(e.writer)
+		if f == nil {
+			if ew := writerEnabler(e.writer); ew != nil {
+				levels.enablers = append(levels.enablers, ew)
+			} else {
+				levels.unfiltered = true
+			}
+			continue
+		}
+		levels.filters = append(levels.filters, f)
+		levels.states = append(levels.states, f.load())
+	}
+	runtime.levels.Store(levels)
+	return levels
+}
+
+func (levels *enabledLevels) valid(runtime *Runtime) bool {
+	if levels.writersVersion != atomic.LoadUint64(&runtime.writersVersion) {
+		return false
+	}
+	for i, f := range levels.filters {
+		if f.load() != levels.states[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (levels *enabledLevels) priority(pkg string) LogPriority {
+	if priority, ok := levels.cache.Load(pkg); ok {
+		return priority.(LogPriority)
+	}
+	priority := LogPriority(math.MaxInt32)
+	for _, state := range levels.states {
+		if p := state.priority(pkg); p < priority {
+			priority = p
+		}
+	}
+	levels.cache.Store(pkg, priority)
+	return priority
+}
+
+// SetLevel changes the minimum level of packages matching the pattern on the default runtime.
+func SetLevel(pattern string, level *LogLevel) {
+	defaultRuntime.SetLevel(pattern, level)
+}
+
+// UnsetLevel removes the pattern from the default runtime.
+func UnsetLevel(pattern string) {
+	defaultRuntime.UnsetLevel(pattern)
+}
+
+// GetLevels returns the minimum levels by pattern of the default runtime.
+func GetLevels() map[string]*LogLevel {
+	return defaultRuntime.GetLevels()
+}
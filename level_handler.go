@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LevelHandler returns an HTTP handler changing levels of the runtime.
+//
+//	GET    lists the current levels by pattern, e.g. {"*":"INFO","db.*":"DEBUG"}
+//	PUT    changes a level with a body like {"pattern":"db.*","level":"debug","ttl":"10m"}.
+//	       If "ttl" is given, the previous level is restored after the duration.
+//	DELETE removes a pattern given by "pattern" query parameter.
+func (runtime *Runtime) LevelHandler() http.Handler {
+	return &levelHandler{
+		runtime:  runtime,
+		restores: map[string]*pendingRestore{},
+	}
+}
+
+// LevelHandler returns an HTTP handler changing levels of the default runtime. See Runtime.LevelHandler.
+func LevelHandler() http.Handler {
+	return defaultRuntime.LevelHandler()
+}
+
+type levelHandler struct {
+	runtime *Runtime
+
+	lock sync.Mutex
+	// restores holds levels to be restored after TTL by pattern.
+	restores map[string]*pendingRestore
+}
+
+type pendingRestore struct {
+	timer   *time.Timer
+	restore func()
+}
+
+type levelChangeRequest struct {
+	Pattern string `json:"pattern"`
+	Level   string `json:"level"`
+	TTL     string `json:"ttl"`
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeLevels(w)
+
+	case http.MethodPut:
+		var req levelChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Pattern == "" {
+			http.Error(w, "pattern is required", http.StatusBadRequest)
+			return
+		}
+		level, err := ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+				http.Error(w, fmt.Sprintf("invalid ttl: %q", req.TTL), http.StatusBadRequest)
+				return
+			}
+		}
+		h.change(req.Pattern, level, ttl)
+		h.writeLevels(w)
+
+	case http.MethodDelete:
+		pattern := r.URL.Query().Get("pattern")
+		if pattern == "" {
+			http.Error(w, "pattern is required", http.StatusBadRequest)
+			return
+		}
+		h.change(pattern, nil, 0)
+		h.writeLevels(w)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// change sets the level of the pattern, or removes it if the level is nil.
+// A pending restore of the pattern is cancelled, but the original level is kept
+// to be restored if TTL is given again.
+func (h *levelHandler) change(pattern string, level *LogLevel, ttl time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	restore := h.runtime.swapLevel(pattern, level)
+	if pending, ok := h.restores[pattern]; ok {
+		pending.timer.Stop()
+		restore = pending.restore
+		delete(h.restores, pattern)
+	}
+	if ttl == 0 {
+		return
+	}
+
+	pending := &pendingRestore{restore: restore}
+	pending.timer = time.AfterFunc(ttl, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		if h.restores[pattern] == pending {
+			pending.restore()
+			delete(h.restores, pattern)
+		}
+	})
+	h.restores[pattern] = pending
+}
+
+func (h *levelHandler) writeLevels(w http.ResponseWriter) {
+	levels := h.runtime.GetLevels()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levels)
+}
@@ -1,9 +1,6 @@
 package logger
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -30,79 +27,149 @@ type Log struct {
 	Elapsed     int64     `json:"elapsed"`
 	ElapsedNano int64     `json:"elapsed_nano"`
 
+	// Err is the error given to Error or Wtf.
+	Err *ErrorInfo `json:"error,omitempty"`
+
+	// File, Line and Function are the call site of the log, recorded if caller capture is enabled.
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Function string `json:"func,omitempty"`
+
 	// only shown in console (purged attributes)
 	DisplayedAttrs *Attrs `json:"-"`
+
+	// Fields are the attributes in the given order, with their types. Attrs and DisplayedAttrs are
+	// built from them only for writers reading the maps, and writers fall back to Attrs if Fields is nil.
+	Fields Fields `json:"-"`
+	// DisplayedFields are the fields not used in the message. It may share the underlying array with Fields.
+	DisplayedFields Fields `json:"-"`
+
+	// runtime is the runtime of the timer which created the log.
+	runtime       *Runtime
+	captureCaller bool
+	callerSkip    int
 }
 
 func (log *Log) End(msg string, args ...interface{}) {
-	attrs := MergeAttrs(args)
+	if log.runtime == nil {
+		log.runtime = defaultRuntime
+	}
+	if !log.runtime.Enabled(log.Package, log.Level) {
+		return
+	}
+	emit, suppressed := log.runtime.sampler.Check(log.Package, log.Level, msg)
+	if !emit {
+		return
+	}
+	formatted, attrs, displayed := log.runtime.prepare(msg, "", args, suppressed)
 	elapsed := Now() - log.Time
-	formatted, purgedAttrs := Format(msg, *attrs)
 
-	log.DisplayedAttrs = &purgedAttrs
-	log.Attrs = attrs
+	log.setFields(attrs, displayed)
 	log.Elapsed = elapsed / 1000000
 	log.ElapsedNano = elapsed
 	log.Message = formatted
+	if log.captureCaller {
+		log.File, log.Line, log.Function = captureCaller(log.callerSkip)
+	}
 
-	runtime.Log(log)
+	log.runtime.Log(log)
 }
 
-func MergeAttrs(v []interface{}) *Attrs {
-	if len(v) == 0 {
-		return new(Attrs)
+// setFields sets the fields of the log. Attributes built from previous fields are discarded.
+func (log *Log) setFields(fields, displayed Fields) {
+	log.Fields, log.DisplayedFields = fields, displayed
+	log.Attrs, log.DisplayedAttrs = nil, nil
+}
+
+// buildAttrs sets Attrs and DisplayedAttrs from the fields, if they aren't set yet.
+func (log *Log) buildAttrs() {
+	if log.Attrs != nil {
+		return
+	}
+	attrs, displayed := log.Fields.Attrs(), log.DisplayedFields.Attrs()
+	log.Attrs, log.DisplayedAttrs = &attrs, &displayed
+}
+
+// fields returns the fields of the log, or the fields converted from Attrs if the log has no Fields.
+func (log *Log) fields() Fields {
+	if log.Fields == nil && log.Attrs != nil {
+		return Fields(nil).setAttrs(*log.Attrs)
 	}
+	return log.Fields
+}
 
-	attrs, ok := v[len(v)-1].(Attrs)
-	if !ok {
-		// use empty one
-		attrs = Attrs{}
-	} else {
-		// remove last argument
-		v = v[:len(v)-1]
+// displayedFields returns DisplayedFields, or the fields converted from DisplayedAttrs.
+func (log *Log) displayedFields() Fields {
+	if log.DisplayedFields == nil && log.DisplayedAttrs != nil {
+		return Fields(nil).setAttrs(*log.DisplayedAttrs)
 	}
+	return log.DisplayedFields
+}
 
-	// convert list to map with string index (e.g. ["foo", "bar"] -> {"0": "foo", "1": "bar"}
-	for i, value := range v {
-		key := strconv.Itoa(i)
-		attrs[key] = value
+// prepare resolves arguments of a log into attributes, and formats the message from the template
+// followed by the text, which isn't parsed so dynamic text (e.g. error messages) doesn't fill the
+// template cache. Redaction rules of the runtime are applied to both.
+func (runtime *Runtime) prepare(template, text string, args []interface{}, suppressed uint64) (formatted string, attrs, displayed Fields) {
+	attrs = resolveValues(collectFields(args))
+	if suppressed > 0 {
+		attrs = attrs.set(Any(SuppressedAttrKey, suppressed))
 	}
+	redaction := runtime.redactionRules()
+	dropped := redaction.redactFields(attrs)
+
+	formatted, displayed = formatFields(template, attrs)
+	formatted = redaction.redactString(formatted + text)
+	if dropped {
+		attrs, displayed = removeDropped(attrs), removeDropped(displayed)
+	}
+	return formatted, attrs, displayed
+}
+
+// MergeAttrs converts arguments of a log to attributes. See Logger for the arguments.
+func MergeAttrs(v []interface{}) *Attrs {
+	attrs := collectFields(v).Attrs()
 	return &attrs
 }
 
-// Format formats string with Python style (PEP 3101, especially key-value formatting through brackets).
-// returns unmatched attributes, for better printing.
+// Format formats string with Python style (PEP 3101). Replacement fields support:
+//
+//	{key} {} {0}              attribute by key, or positional arguments
+//	{user.Name} {items[0]}    attribute and index access
+//	{addr!r}                  conversion (!r quotes, !a quotes in ASCII, !s)
+//	{amount:.2f} {id:08d}     format spec: [[fill]align][sign][#][0][width][,][.precision][type]
+//	{count:,} {name:>12}
+//
+// Brackets are escaped by doubling them ("{{", "}}"). Fields which can't be resolved are rendered as
+// "%!{key}(MISSING)". It returns unmatched attributes, for better printing.
+// Format strings are parsed once and cached.
 func Format(format string, attrs Attrs) (formatted string, purged Attrs) {
-	formatted = format
-	purged = Attrs{}
+	if loadEncoders().attrsNeedResolve(attrs) {
+		formatted, displayed := formatFields(format, resolveValues(Fields(nil).setAttrs(attrs)))
+		return formatted, displayed.Attrs()
+	}
+
+	var usedKeys [8]string
+	formatted, used := lookupTemplate(format).render(attrSource{attrs: attrs}, usedKeys[:0])
 
+	purged = make(Attrs, len(attrs))
 	for key, value := range attrs {
-		placeholder := "{" + key + "}"
-		if strings.Contains(format, placeholder) && !strings.Contains(format, "{{"+key+"}}") {
-			valueStr := fmt.Sprintf("%v", value)
-			formatted = strings.Replace(formatted, placeholder, valueStr, -1)
-		} else {
+		if !containsString(used, key) {
 			purged[key] = value
 		}
 	}
+	return formatted, purged
+}
 
-	// assign positional arguments for empty brackets
-	if _, ok := purged["0"]; ok {
-		index := 0
-		for strings.Contains(formatted, "{}") {
-			key := strconv.Itoa(index)
-			value := fmt.Sprintf("%v", purged[key])
-			formatted = strings.Replace(formatted, "{}", value, 1)
-
-			delete(purged, key)
-			index++
-		}
+// formatFields formats string like Format, and returns fields not used in the message.
+// If no field is used, the fields are returned as is.
+func formatFields(format string, fields Fields) (formatted string, displayed Fields) {
+	var usedKeys [8]string
+	formatted, used := lookupTemplate(format).render(attrSource{fields: fields}, usedKeys[:0])
+	if len(used) == 0 {
+		return formatted, fields
 	}
 
-	// escape by doubling brackets
-	escape := strings.NewReplacer("{{", "{", "}}", "}")
-	formatted = escape.Replace(formatted)
-	return
+	return formatted, fields.without(used, "")
 }
 
 // Now is a shortcut for returning the current time in Unix nanoseconds.
@@ -2,6 +2,10 @@ package logger
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 type LogPriority int
@@ -15,6 +19,9 @@ type LogLevel struct {
 	Name     string
 	Color    string
 	Priority LogPriority
+
+	// Abbrev is shown in the console output. Defaults to the first letter of the name.
+	Abbrev string
 }
 
 func (lvl *LogLevel) String() string {
@@ -22,6 +29,9 @@ func (lvl *LogLevel) String() string {
 }
 
 func (lvl *LogLevel) Symbol() string {
+	if lvl.Abbrev != "" {
+		return lvl.Abbrev
+	}
 	return string(lvl.Name[0])
 }
 
@@ -29,6 +39,26 @@ func (lvl *LogLevel) MarshalJSON() ([]byte, error) {
 	return json.Marshal(lvl.String())
 }
 
+func (lvl *LogLevel) MarshalText() ([]byte, error) {
+	return []byte(lvl.String()), nil
+}
+
+// UnmarshalText copies a registered level with the name (case-insensitive) into lvl.
+// Note that the copy is a different pointer from the registered one, so compare levels by Priority.
+func (lvl *LogLevel) UnmarshalText(text []byte) error {
+	found, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lvl = *found
+	return nil
+}
+
+// Set implements flag.Value.
+func (lvl *LogLevel) Set(name string) error {
+	return lvl.UnmarshalText([]byte(name))
+}
+
 var (
 	Verbose = addLogLevel("Verbose", dim, 0)
 	Debug   = addLogLevel("DEBUG", white, 1)
@@ -38,7 +68,9 @@ var (
 	Error   = addLogLevel("ERROR", Red, 10)
 	Fatal   = addLogLevel("FATAL", Red, 99)
 
+	// logLevelNameMap holds levels by upper-cased name.
 	logLevelNameMap = map[string]*LogLevel{}
+	logLevelLock    sync.RWMutex
 )
 
 func addLogLevel(name, color string, priority LogPriority) *LogLevel {
@@ -47,6 +79,90 @@ func addLogLevel(name, color string, priority LogPriority) *LogLevel {
 		Color:    color,
 		Priority: priority,
 	}
-	logLevelNameMap[name] = lvl
+	logLevelNameMap[strings.ToUpper(name)] = lvl
+	return lvl
+}
+
+// RegisterLevel adds a custom level (e.g. TRACE, NOTICE, AUDIT), which can be used with Logger.Log
+// and in LOG settings (e.g. LOG=*@notice). Name, priority and symbol (abbrev) must not collide
+// with registered levels. If abbrev is empty, the first letter of the name is used.
+func RegisterLevel(name, abbrev, color string, priority LogPriority) (*LogLevel, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "@,~* ") {
+		return nil, fmt.Errorf("invalid level name: %q", name)
+	}
+	if strings.EqualFold(name, "mute") {
+		return nil, fmt.Errorf("level name %q is reserved", name)
+	}
+	lvl := &LogLevel{
+		Name:     name,
+		Color:    color,
+		Priority: priority,
+		Abbrev:   abbrev,
+	}
+	if utf8.RuneCountInString(lvl.Symbol()) != 1 {
+		return nil, fmt.Errorf("level symbol must be a letter: %q", lvl.Symbol())
+	}
+
+	logLevelLock.Lock()
+	defer logLevelLock.Unlock()
+
+	for _, registered := range logLevelNameMap {
+		switch {
+		case strings.EqualFold(registered.Name, name):
+			return nil, fmt.Errorf("level %s is already registered", registered.Name)
+		case registered.Priority == priority:
+			return nil, fmt.Errorf("priority %d is already used by level %s", priority, registered.Name)
+		case registered.Symbol() == lvl.Symbol():
+			return nil, fmt.Errorf("symbol %s is already used by level %s", lvl.Symbol(), registered.Name)
+		}
+	}
+	logLevelNameMap[strings.ToUpper(name)] = lvl
+	return lvl, nil
+}
+
+// unregisterLevel removes a level registered by RegisterLevel.
+func unregisterLevel(name string) {
+	logLevelLock.Lock()
+	defer logLevelLock.Unlock()
+
+	delete(logLevelNameMap, strings.ToUpper(name))
+}
+
+// MustRegisterLevel is like RegisterLevel, but panics on an error.
+func MustRegisterLevel(name, abbrev, color string, priority LogPriority) *LogLevel {
+	lvl, err := RegisterLevel(name, abbrev, color, priority)
+	if err != nil {
+		panic(err)
+	}
 	return lvl
 }
+
+// ParseLevel returns a registered level with the name, case-insensitively.
+// "mute" is accepted as the fatal level.
+func ParseLevel(name string) (*LogLevel, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "MUTE" {
+		return Fatal, nil
+	}
+	logLevelLock.RLock()
+	defer logLevelLock.RUnlock()
+
+	if lvl, ok := logLevelNameMap[name]; ok {
+		return lvl, nil
+	}
+	return nil, fmt.Errorf("unknown level: %q", name)
+}
+
+// levelOfPriority returns a registered level with the priority.
+func levelOfPriority(priority LogPriority) *LogLevel {
+	logLevelLock.RLock()
+	defer logLevelLock.RUnlock()
+
+	for _, lvl := range logLevelNameMap {
+		if lvl.Priority == priority {
+			return lvl
+		}
+	}
+	return &LogLevel{Name: fmt.Sprint(priority), Priority: priority}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegisterLevel(t *testing.T) {
+	convey.Convey("When a custom level is registered", t, func() {
+		convey.Convey("It should reject collisions", func() {
+			_, err := RegisterLevel("info", "", Reset, 50)
+			convey.So(err, convey.ShouldNotBeNil)
+
+			_, err = RegisterLevel("NOTICE", "", Reset, Warn.Priority)
+			convey.So(err, convey.ShouldNotBeNil)
+
+			_, err = RegisterLevel("TRACE", "", dim, -1)
+			convey.So(err, convey.ShouldNotBeNil)
+		})
+
+		convey.Convey("It should be parsed by name and priority", func() {
+			trace, err := RegisterLevel("TRACE", "R", dim, -1)
+			convey.So(err, convey.ShouldBeNil)
+			defer unregisterLevel("TRACE")
+			convey.So(trace.Symbol(), convey.ShouldEqual, "R")
+
+			parsed, err := ParseLevel("trace")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(parsed, convey.ShouldEqual, trace)
+			convey.So(parsePackageSettings("db@trace", 0)["db"], convey.ShouldEqual, LogPriority(-1))
+		})
+	})
+
+	convey.Convey("LogLevel should be read from configs and flags", t, func() {
+		var config struct {
+			Level LogLevel `json:"level"`
+		}
+		convey.So(json.Unmarshal([]byte(`{"level":"warn"}`), &config), convey.ShouldBeNil)
+		convey.So(config.Level.Priority, convey.ShouldEqual, Warn.Priority)
+		convey.So(json.Unmarshal([]byte(`{"level":"loud"}`), &config), convey.ShouldNotBeNil)
+
+		var level LogLevel
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.Var(&level, "level", "log level")
+		convey.So(flags.Parse([]string{"-level", "error"}), convey.ShouldBeNil)
+		convey.So(level.Name, convey.ShouldEqual, "ERROR")
+	})
+}
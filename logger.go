@@ -1,12 +1,22 @@
 package logger
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"os"
+	"time"
+)
+
+const (
+	// fatalFlushTimeout limits time to flush logs before exiting by Fatal.
+	fatalFlushTimeout = 5 * time.Second
 )
 
 // Logger is the unit of the logger package, a smart, pretty-printing gate between
 // the program and the output stream.
+//
+// Arguments of the logging methods are positional values referenced by "{}" or "{0}" in the message,
+// and attributes given as Attrs, Field or Fields (e.g. logger.String("user", name)).
 type Logger interface {
 	Log(level *LogLevel, message string, args []interface{})
 	Verbose(msg string, v ...interface{})
@@ -15,41 +25,118 @@ type Logger interface {
 	Warn(msg string, v ...interface{})
 	Error(msg string, v ...interface{})
 
+	// Context-aware variants log the attributes attached to the context by ContextWithAttrs.
+	LogCtx(ctx context.Context, level *LogLevel, message string, args []interface{})
+	VerboseCtx(ctx context.Context, msg string, v ...interface{})
+	DebugCtx(ctx context.Context, msg string, v ...interface{})
+	InfoCtx(ctx context.Context, msg string, v ...interface{})
+	WarnCtx(ctx context.Context, msg string, v ...interface{})
+	ErrorCtx(ctx context.Context, msg string, v ...interface{})
+
 	Timer() *Log
 
+	// Enabled returns whether logs of the level are emitted by any writer.
+	// It can be used to skip expensive work to prepare a log.
+	Enabled(level *LogLevel) bool
+
 	Fatal(v ...interface{})
 	Wtf(v ...interface{})
 	Recover(optionalContext ...Attrs) *PanicError
 
 	// WithAttrs returns a sub-logger with given attributes attached as a default.
 	WithAttrs(attrs Attrs) Logger
+
+	// WithGroup returns a sub-logger nesting attributes given to it under the name
+	// (e.g. "http" -> http.method=GET). Attributes of the parent and the context are not nested.
+	WithGroup(name string) Logger
+
+	// Writer returns a writer logging every line written to it with the level, for programs and
+	// libraries writing to an io.Writer. Closing it logs the last line if it isn't terminated.
+	Writer(level *LogLevel, opts ...WriterOption) io.WriteCloser
+
+	// Named returns a child logger whose name is appended to the name of the logger with a dot
+	// (e.g. "db" -> "db.postgres"), so it can be filtered hierarchically like "db.*".
+	Named(name string) Logger
 }
 
-// New returns a logger bound to the given name.
-func New(name string) Logger {
-	return &logger{
-		Name: name,
-	}
+// New returns a logger bound to the given name, writing to the default runtime.
+func New(name string, opts ...Option) Logger {
+	return defaultRuntime.New(name, opts...)
 }
 
 type logger struct {
 	// Name by which the logger is identified when enabling or disabling it, and by envvar.
 	Name string
+
+	runtime    *Runtime
+	caller     callerMode
+	callerSkip int
+}
+
+func (l *logger) shouldCaptureCaller() bool {
+	switch l.caller {
+	case callerEnabled:
+		return true
+	case callerDisabled:
+		return false
+	}
+	return l.runtime.capturesCaller()
 }
 
 func (l *logger) Log(level *LogLevel, message string, args []interface{}) {
-	attrs := MergeAttrs(args)
-	formatted, purgedAttrs := Format(message, *attrs)
+	l.log(level, message, message, "", args, nil)
+}
 
-	runtime.Log(&Log{
+// logText logs the text as is, without parsing it as a template.
+func (l *logger) logText(level *LogLevel, text string, args []interface{}) {
+	l.log(level, text, "", text, args, nil)
+}
+
+// textLogger is implemented by loggers of this package, which log dynamic text (e.g. lines written
+// by other programs) without parsing it as a template.
+type textLogger interface {
+	logText(level *LogLevel, text string, args []interface{})
+}
+
+// logText logs the text as is. Loggers implemented outside of the package are given the text
+// with brackets escaped.
+func logText(l Logger, level *LogLevel, text string, args []interface{}) {
+	if tl, ok := l.(textLogger); ok {
+		tl.logText(level, text, args)
+		return
+	}
+	l.Log(level, escapeBraces(text), args)
+}
+
+// log writes the log with an optional error, which is captured structurally by NewErrorInfo.
+// The message is formatted from the template, followed by the text as is. Logs are sampled by the key,
+// which is the message given by the caller before the error is added.
+func (l *logger) log(level *LogLevel, key, template, text string, args []interface{}, err error) {
+	if !l.runtime.Enabled(l.Name, level) {
+		return
+	}
+	emit, suppressed := l.runtime.sampler.Check(l.Name, level, key)
+	if !emit {
+		return
+	}
+	formatted, attrs, displayed := l.runtime.prepare(template, text, args, suppressed)
+
+	log := &Log{
 		Package: l.Name,
 		Level:   level,
 		Message: formatted,
 		Time:    Now(),
-		Attrs:   attrs,
 
-		DisplayedAttrs: &purgedAttrs,
-	})
+		Fields:          attrs,
+		DisplayedFields: displayed,
+	}
+	if err != nil {
+		log.Err = l.runtime.redactionRules().redactErrorInfo(NewErrorInfo(err))
+	}
+	if l.shouldCaptureCaller() {
+		log.File, log.Line, log.Function = captureCaller(l.callerSkip)
+	}
+	l.runtime.Log(log)
 }
 
 // Verbose logs messages that logged frequently (e.g. request logs).
@@ -73,15 +160,46 @@ func (l *logger) Warn(msg string, v ...interface{}) {
 }
 
 // Error logs an error message.
-// If error has been given as a first argument, the error will be logged also.
+// If error has been given as a first argument, the error will be logged also,
+// with its chain and stack trace captured to Log.Err.
 func (l *logger) Error(msg string, v ...interface{}) {
+	var err error
+	text := ""
 	if len(v) > 0 {
-		if err, hasErr := v[0].(error); hasErr {
-			msg = fmt.Sprintf("%s: %v", msg, err)
+		if e, hasErr := v[0].(error); hasErr {
+			err = e
+			text = ": " + err.Error()
 			v = v[1:]
 		}
 	}
-	l.Log(Error, msg, v)
+	l.log(Error, msg, msg, text, v, err)
+}
+
+func (l *logger) LogCtx(ctx context.Context, level *LogLevel, message string, args []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.Log(level, message, withContextAttrs(ctx, args))
+}
+
+func (l *logger) VerboseCtx(ctx context.Context, msg string, v ...interface{}) {
+	l.LogCtx(ctx, Verbose, msg, v)
+}
+
+func (l *logger) DebugCtx(ctx context.Context, msg string, v ...interface{}) {
+	l.LogCtx(ctx, Debug, msg, v)
+}
+
+func (l *logger) InfoCtx(ctx context.Context, msg string, v ...interface{}) {
+	l.LogCtx(ctx, Info, msg, v)
+}
+
+func (l *logger) WarnCtx(ctx context.Context, msg string, v ...interface{}) {
+	l.LogCtx(ctx, Warn, msg, v)
+}
+
+func (l *logger) ErrorCtx(ctx context.Context, msg string, v ...interface{}) {
+	l.Error(msg, withContextAttrs(ctx, v)...)
 }
 
 // Wtf logs error detailed, and reports error to error transport.
@@ -89,52 +207,86 @@ func (l *logger) Error(msg string, v ...interface{}) {
 // TODO: add transports for errors reported with WTF level
 func (l *logger) Wtf(v ...interface{}) {
 	msg := ""
-	if m, ok := v[0].(string); ok {
-		msg = m
-		v = v[1:]
+	if len(v) > 0 {
+		if m, ok := v[0].(string); ok {
+			msg = m
+			v = v[1:]
+		}
 	}
+	var err error
+	text := ""
 	if len(v) > 0 {
-		if err, hasErr := v[0].(error); hasErr {
+		if e, hasErr := v[0].(error); hasErr {
+			err = e
 			if msg != "" {
-				msg = msg + ": "
+				text = ": "
 			}
-			msg = msg + fmt.Sprintf("%+v", err)
+			text += err.Error()
 			v = v[1:]
 		}
 	}
-	l.Log(Fatal, Colored(Red, msg), v)
+	l.log(Fatal, msg, Red+msg, text+Reset, v, err)
 }
 
 // Fatal behaves same as Wtf, but it exits process with code 1
+// after flushing buffered logs.
 func (l *logger) Fatal(v ...interface{}) {
 	l.Wtf(v...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	_ = l.runtime.Flush(ctx)
+	cancel()
 	os.Exit(1)
 }
 
 func (l *logger) Recover(optionalContext ...Attrs) *PanicError {
 	if r := WrapRecover(recover()); r != nil {
+		var args []interface{}
 		if len(optionalContext) > 0 {
-			l.Wtf(r.Pretty(), optionalContext[0])
-		} else {
-			l.Wtf(r.Pretty())
+			args = append(args, optionalContext[0])
 		}
+		pretty := r.Pretty()
+		l.log(Fatal, pretty, Red, pretty+Reset, args, nil)
 		return r
 	}
 	return nil
 }
 
+// Enabled returns whether logs of the level are emitted by any writer of the runtime.
+func (l *logger) Enabled(level *LogLevel) bool {
+	return l.runtime.Enabled(l.Name, level)
+}
+
 // Timer returns a timer sub-logger.
 func (l *logger) Timer() *Log {
 	return &Log{
 		Package: l.Name,
 		Level:   Timer,
 		Time:    Now(),
+		runtime: l.runtime,
+
+		captureCaller: l.shouldCaptureCaller(),
+		callerSkip:    l.callerSkip,
 	}
 }
 
 func (l *logger) WithAttrs(attr Attrs) Logger {
-	return &subLogger{
-		parent:       l,
-		defaultAttrs: attr,
+	return newSubLogger(l, attr)
+}
+
+func (l *logger) WithGroup(name string) Logger {
+	return newGroupLogger(l, name)
+}
+
+func (l *logger) Writer(level *LogLevel, opts ...WriterOption) io.WriteCloser {
+	return newLineWriter(l, level, opts)
+}
+
+func (l *logger) Named(name string) Logger {
+	child := *l
+	if l.Name != "" {
+		name = l.Name + "." + name
 	}
+	child.Name = name
+	return &child
 }
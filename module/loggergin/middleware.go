@@ -13,6 +13,18 @@ func Middleware(loggerName string, opt ...Option) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		timer := log.Timer()
+
+		requestID := ""
+		if o.requestIDHeader != "" {
+			requestID = c.GetHeader(o.requestIDHeader)
+			if requestID == "" {
+				requestID = logger.NewRequestID()
+			}
+			c.Header(o.requestIDHeader, requestID)
+
+			ctx := logger.ContextWithAttrs(c.Request.Context(), logger.Attrs{"request_id": requestID})
+			c.Request = c.Request.WithContext(ctx)
+		}
 		c.Next()
 
 		statusCode := c.Writer.Status()
@@ -29,11 +41,14 @@ func Middleware(loggerName string, opt ...Option) gin.HandlerFunc {
 			"status": statusCode,
 			"client": c.ClientIP(),
 		}
+		if requestID != "" {
+			info["request_id"] = requestID
+		}
 		if o.disableColor {
 			timer.End("{method} {url} – HTTP {status} – {client}", info)
 			return
 		}
-		timer.End("{method} {url} – {}HTTP {status}{} – {client}", statusColor, logger.Reset, info)
+		timer.End("{method} {url} – "+statusColor+"HTTP {status}"+logger.Reset+" – {client}", info)
 	}
 }
 
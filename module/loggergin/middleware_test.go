@@ -0,0 +1,56 @@
+package loggergin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/airbloc/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	convey.Convey("Given a router with request IDs", t, func() {
+		var requestID interface{}
+		router := gin.New()
+		router.Use(Middleware("http", WithRequestID("X-Request-ID")))
+		router.GET("/", func(c *gin.Context) {
+			requestID = logger.AttrsFromContext(c.Request.Context())["request_id"]
+		})
+
+		convey.Convey("The ID of the request should be attached to the context", func() {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "r1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			convey.So(requestID, convey.ShouldEqual, "r1")
+			convey.So(w.Header().Get("X-Request-ID"), convey.ShouldEqual, "r1")
+		})
+
+		convey.Convey("A new ID should be generated if the request has none", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			convey.So(requestID, convey.ShouldHaveLength, 16)
+			convey.So(w.Header().Get("X-Request-ID"), convey.ShouldEqual, requestID)
+		})
+	})
+
+	convey.Convey("Request IDs should not be attached unless enabled", t, func() {
+		var attrs logger.Attrs
+		router := gin.New()
+		router.Use(Middleware("http"))
+		router.GET("/", func(c *gin.Context) {
+			attrs = logger.AttrsFromContext(c.Request.Context())
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		convey.So(attrs, convey.ShouldBeNil)
+		convey.So(w.Header().Get("X-Request-ID"), convey.ShouldBeEmpty)
+	})
+}
@@ -1,8 +1,9 @@
 package loggergin
 
 type options struct {
-	disableColor  bool
-	disable404Log bool
+	disableColor    bool
+	disable404Log   bool
+	requestIDHeader string
 }
 
 type Option func(o *options)
@@ -19,6 +20,15 @@ func WithDisableColors() Option {
 	}
 }
 
+// WithRequestID attaches a request ID taken from given header (e.g. "X-Request-ID") to the
+// request context, so it is logged by context-aware methods like logger.InfoCtx.
+// If the header is missing, a new ID is generated and set to the response header.
+func WithRequestID(header string) Option {
+	return func(o *options) {
+		o.requestIDHeader = header
+	}
+}
+
 func buildOptions(opts []Option) *options {
 	o := new(options)
 	for _, optFn := range opts {
@@ -3,8 +3,9 @@ package loggergrpc
 import "github.com/airbloc/logger"
 
 type options struct {
-	requestLogLevel *logger.LogLevel
-	errorLogLevel   *logger.LogLevel
+	requestLogLevel   *logger.LogLevel
+	errorLogLevel     *logger.LogLevel
+	requestIDMetadata string
 }
 
 type Option func(o *options)
@@ -23,10 +24,19 @@ func WithErrorLogLevel(level *logger.LogLevel) Option {
 	}
 }
 
+// WithRequestIDMetadata attaches a request ID taken from given incoming metadata key
+// (e.g. "x-request-id") to the handler context, so it is logged by context-aware methods
+// like logger.InfoCtx. If the key is missing, a new ID is generated and sent as a header.
+func WithRequestIDMetadata(key string) Option {
+	return func(o *options) {
+		o.requestIDMetadata = key
+	}
+}
+
 func createOptions(opts []Option) *options {
 	opt := &options{
 		requestLogLevel: logger.Verbose,
-		errorLogLevel: logger.Error,
+		errorLogLevel:   logger.Error,
 	}
 	for _, fn := range opts {
 		fn(opt)
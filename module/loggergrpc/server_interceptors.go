@@ -5,6 +5,7 @@ import (
 	"github.com/airbloc/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"time"
 )
@@ -13,19 +14,20 @@ import (
 func UnaryServerLogger(log logger.Logger, opts ...Option) grpc.UnaryServerInterceptor {
 	opt := createOptions(opts)
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx = attachRequestID(ctx, opt)
 		startTime := time.Now()
 		resp, err = handler(ctx, req)
 		elapsed := time.Now().Sub(startTime).String()
 
 		if panicErr, ok := err.(*logger.PanicError); ok {
 			err = status.Error(codes.Internal, panicErr.Error())
-			log.Log(opt.errorLogLevel, "Request {}({}) – {}", []interface{}{info.FullMethod, elapsed, panicErr.Pretty()})
+			log.LogCtx(ctx, opt.errorLogLevel, "Request {}({}) – {}", []interface{}{info.FullMethod, elapsed, panicErr.Pretty()})
 
 		} else if s := status.Convert(err); s != nil {
 			code := grpcCodeToString[s.Code()]
-			log.Log(opt.errorLogLevel, "Request {}({}) – {}: {}", []interface{}{info.FullMethod, elapsed, code, s.Message()})
+			log.LogCtx(ctx, opt.errorLogLevel, "Request {}({}) – {}: {}", []interface{}{info.FullMethod, elapsed, code, s.Message()})
 		} else {
-			log.Log(opt.requestLogLevel, "Request {}({}) – OK", []interface{}{info.FullMethod, elapsed})
+			log.LogCtx(ctx, opt.requestLogLevel, "Request {}({}) – OK", []interface{}{info.FullMethod, elapsed})
 		}
 		return
 	}
@@ -48,19 +50,23 @@ func UnaryServerRecover() grpc.UnaryServerInterceptor {
 func StreamServerLogger(log logger.Logger, opts ...Option) grpc.StreamServerInterceptor {
 	opt := createOptions(opts)
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := attachRequestID(ss.Context(), opt)
+		if ctx != ss.Context() {
+			ss = &serverStreamWithContext{ServerStream: ss, ctx: ctx}
+		}
 		startTime := time.Now()
 		err := handler(srv, ss)
 		elapsed := time.Now().Sub(startTime).String()
 
 		if panicErr, ok := err.(*logger.PanicError); ok {
 			err = status.Error(codes.Internal, panicErr.Error())
-			log.Log(opt.errorLogLevel, "Streaming {}({}) – {}", []interface{}{info.FullMethod, elapsed, panicErr.Pretty()})
+			log.LogCtx(ctx, opt.errorLogLevel, "Streaming {}({}) – {}", []interface{}{info.FullMethod, elapsed, panicErr.Pretty()})
 
 		} else if s := status.Convert(err); s != nil {
 			code := grpcCodeToString[s.Code()]
-			log.Log(opt.errorLogLevel, "Streaming {}({}) – {}: {}", []interface{}{info.FullMethod, elapsed, code, s.Message()})
+			log.LogCtx(ctx, opt.errorLogLevel, "Streaming {}({}) – {}: {}", []interface{}{info.FullMethod, elapsed, code, s.Message()})
 		} else {
-			log.Log(opt.requestLogLevel, "Streaming {}({}) – OK", []interface{}{info.FullMethod, elapsed})
+			log.LogCtx(ctx, opt.requestLogLevel, "Streaming {}({}) – OK", []interface{}{info.FullMethod, elapsed})
 		}
 		return err
 	}
@@ -78,3 +84,30 @@ func StreamServerRecover() grpc.StreamServerInterceptor {
 		return handler(srv, ss)
 	}
 }
+
+// attachRequestID returns a context carrying the request ID attributes if enabled by options.
+func attachRequestID(ctx context.Context, opt *options) context.Context {
+	if opt.requestIDMetadata == "" {
+		return ctx
+	}
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(opt.requestIDMetadata); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = logger.NewRequestID()
+		_ = grpc.SetHeader(ctx, metadata.Pairs(opt.requestIDMetadata, requestID))
+	}
+	return logger.ContextWithAttrs(ctx, logger.Attrs{"request_id": requestID})
+}
+
+type serverStreamWithContext struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStreamWithContext) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,42 @@
+package loggergrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airbloc/logger"
+	"github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithRequestIDMetadata(t *testing.T) {
+	convey.Convey("Given a unary interceptor with request IDs", t, func() {
+		interceptor := UnaryServerLogger(logger.New("grpc"), WithRequestIDMetadata("x-request-id"))
+		info := &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}
+
+		var requestID interface{}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			requestID = logger.AttrsFromContext(ctx)["request_id"]
+			return nil, nil
+		}
+
+		convey.Convey("The ID in the metadata should be attached to the context", func() {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "r1"))
+			_, err := interceptor(ctx, nil, info, handler)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(requestID, convey.ShouldEqual, "r1")
+		})
+
+		convey.Convey("A new ID should be generated if the metadata has none", func() {
+			_, err := interceptor(context.Background(), nil, info, handler)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(requestID, convey.ShouldHaveLength, 16)
+		})
+	})
+
+	convey.Convey("Request IDs should not be attached unless enabled", t, func() {
+		ctx := attachRequestID(context.Background(), createOptions(nil))
+		convey.So(logger.AttrsFromContext(ctx), convey.ShouldBeNil)
+	})
+}
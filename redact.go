@@ -0,0 +1,271 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in RedactMask mode.
+const RedactedValue = "[REDACTED]"
+
+// RedactionMode tells how sensitive values are redacted.
+type RedactionMode int
+
+const (
+	// RedactMask replaces sensitive values with RedactedValue.
+	RedactMask RedactionMode = iota
+
+	// RedactHash replaces sensitive values with a short SHA-256 hash (e.g. "sha256:5e884898da28"),
+	// so logs with the same value can be correlated.
+	RedactHash
+
+	// RedactDrop removes sensitive attributes. Placeholders of them are rendered as RedactedValue.
+	RedactDrop
+)
+
+// RedactionRule finds sensitive values by one of the key, the value pattern or the function.
+type RedactionRule struct {
+	// Key is a pattern of attribute keys like "password" or "*_token", matched case-insensitively.
+	// "*" matches any sequence of characters.
+	Key string
+
+	// Value matches sensitive parts of string values, like JWTs. If the pattern has a subexpression,
+	// only the first one is redacted (e.g. the value of "token=(\w+)").
+	// Value patterns also apply to messages and errors.
+	Value *regexp.Regexp
+
+	// Func reports whether the attribute is sensitive.
+	Func func(key string, value interface{}) bool
+
+	Mode RedactionMode
+}
+
+var (
+	jwtPattern         = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]+`)
+	creditCardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,15}\d\b`)
+	queryParamsPattern = regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api_?key)=([^&\s]+)`)
+)
+
+// DefaultRedactionRules returns rules masking common credentials: passwords, secrets, tokens,
+// authorization headers and cookies by key, and JWTs, credit card numbers and credentials
+// in query strings by value.
+func DefaultRedactionRules() []RedactionRule {
+	return []RedactionRule{
+		{Key: "password"},
+		{Key: "passwd"},
+		{Key: "*secret*"},
+		{Key: "token"},
+		{Key: "*_token"},
+		{Key: "api_key"},
+		{Key: "authorization"},
+		{Key: "cookie"},
+		{Key: "set-cookie"},
+		{Value: jwtPattern},
+		{Value: creditCardPattern},
+		{Value: queryParamsPattern},
+	}
+}
+
+// redactionRules is an immutable set of rules of a runtime.
+type redactionRules struct {
+	rules []RedactionRule
+
+	// keys are the key patterns in lower case, by the index of rules.
+	keys []string
+
+	hasValueRules bool
+}
+
+// droppedValue is a placeholder of an attribute dropped by RedactDrop,
+// rendered in messages and removed from attributes before writing.
+type droppedValue struct{}
+
+func (droppedValue) String() string {
+	return RedactedValue
+}
+
+func newRedactionRules(rules []RedactionRule) *redactionRules {
+	if len(rules) == 0 {
+		return nil
+	}
+	rs := &redactionRules{
+		rules: append([]RedactionRule(nil), rules...),
+		keys:  make([]string, len(rules)),
+	}
+	for i, rule := range rules {
+		rs.keys[i] = strings.ToLower(rule.Key)
+		if rule.Value != nil {
+			rs.hasValueRules = true
+		}
+	}
+	return rs
+}
+
+// redactFields redacts the fields in place. It returns true if any field is dropped.
+func (rs *redactionRules) redactFields(fields Fields) (dropped bool) {
+	if rs == nil {
+		return false
+	}
+	for i := range fields {
+		if rs.redactField(&fields[i]) {
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+func (rs *redactionRules) redactField(f *Field) (dropped bool) {
+	key := strings.ToLower(f.Key)
+	for i, rule := range rs.rules {
+		if (rs.keys[i] != "" && globMatch(rs.keys[i], key)) || (rule.Func != nil && rule.Func(f.Key, f.Value())) {
+			*f = Any(f.Key, redactValue(f.Value(), rule.Mode))
+			return rule.Mode == RedactDrop
+		}
+	}
+	if group, ok := f.group(); ok {
+		// groups may be shared with sub-loggers
+		group = append(Fields(nil), group...)
+		if rs.redactFields(group) {
+			group = removeDropped(group)
+		}
+		*f = Group(f.Key, group...)
+		return false
+	}
+	if !rs.hasValueRules {
+		return false
+	}
+	s, ok := stringOfValue(f.Value())
+	if !ok {
+		return false
+	}
+	redacted := s
+	for _, rule := range rs.rules {
+		if rule.Value == nil || !rule.Value.MatchString(redacted) {
+			continue
+		}
+		if rule.Mode == RedactDrop {
+			*f = Any(f.Key, droppedValue{})
+			return true
+		}
+		redacted = redactMatches(rule.Value, redacted, rule.Mode)
+	}
+	if redacted != s {
+		*f = String(f.Key, redacted)
+	}
+	return false
+}
+
+// stringOfValue returns the text value patterns are matched against: strings, messages of errors,
+// and string forms of other non-scalar values (e.g. fmt.Stringer, slices). Values not matching
+// are kept as is, and the others are replaced with the redacted string.
+func stringOfValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case error:
+		return v.Error(), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// redactString redacts parts of the text matching value patterns.
+func (rs *redactionRules) redactString(s string) string {
+	if rs == nil || !rs.hasValueRules {
+		return s
+	}
+	for _, rule := range rs.rules {
+		if rule.Value != nil && rule.Value.MatchString(s) {
+			mode := rule.Mode
+			if mode == RedactDrop {
+				mode = RedactMask
+			}
+			s = redactMatches(rule.Value, s, mode)
+		}
+	}
+	return s
+}
+
+// redactErrorInfo redacts messages of the error.
+func (rs *redactionRules) redactErrorInfo(info *ErrorInfo) *ErrorInfo {
+	if rs == nil || !rs.hasValueRules {
+		return info
+	}
+	info.Message = rs.redactString(info.Message)
+	for i, msg := range info.Chain {
+		info.Chain[i] = rs.redactString(msg)
+	}
+	return info
+}
+
+func redactValue(value interface{}, mode RedactionMode) interface{} {
+	switch mode {
+	case RedactHash:
+		return hashValue(fmt.Sprint(value))
+	case RedactDrop:
+		return droppedValue{}
+	}
+	return RedactedValue
+}
+
+// redactMatches replaces the matches of the pattern, or their first subexpressions.
+func redactMatches(pattern *regexp.Regexp, s string, mode RedactionMode) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range pattern.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(redactValue(s[start:end], mode).(string))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func hashValue(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// removeDropped returns fields without the ones dropped by redaction.
+func removeDropped(fields Fields) Fields {
+	kept := make(Fields, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := f.value.(droppedValue); !ok {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// SetRedaction replaces the redaction rules of the runtime. Attributes, messages and errors
+// are redacted before any writer receives the log. Calling it without rules disables redaction.
+func (runtime *Runtime) SetRedaction(rules ...RedactionRule) {
+	runtime.redaction.Store(newRedactionRules(rules))
+}
+
+func (runtime *Runtime) redactionRules() *redactionRules {
+	rs, _ := runtime.redaction.Load().(*redactionRules)
+	return rs
+}
+
+// SetRedaction replaces the redaction rules of the default runtime. See Runtime.SetRedaction.
+func SetRedaction(rules ...RedactionRule) {
+	defaultRuntime.SetRedaction(rules...)
+}
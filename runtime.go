@@ -1,52 +1,395 @@
 package logger
 
 import (
+	"context"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	isterminal "github.com/azer/is-terminal"
 )
 
 var (
-	runtime *Runtime
+	defaultRuntime *Runtime
 )
 
 func init() {
-	runtime = &Runtime{
-		Writers: []OutputWriter{
-			NewStandardOutput(os.Stderr, "", "*"),
-		},
+	output := NewOutput(os.Stderr, "", "*")
+	if window, err := time.ParseDuration(os.Getenv("LOG_DEDUP")); err == nil && window > 0 {
+		output = NewDedupWriter(output, window)
+	}
+	defaultRuntime = NewRuntime(output)
+	if enabled, _ := strconv.ParseBool(os.Getenv("LOG_CALLER")); enabled {
+		defaultRuntime.SetCaptureCaller(true)
+	}
+	for pkg, policy := range parseSamplingSettings(os.Getenv("LOG")) {
+		policy := policy
+		defaultRuntime.SetSampling(pkg, &policy)
 	}
 }
 
+// DefaultRuntime returns the runtime used by New and the package-level functions.
+func DefaultRuntime() *Runtime {
+	return defaultRuntime
+}
+
+// NewOutput returns a writer chosen by LOG_FORMAT environment variable:
+// "json" for JSONWriter, "pretty" (default) for StandardWriter,
+// and "auto" for StandardWriter on a terminal and JSONWriter otherwise.
+func NewOutput(file *os.File, levelSettings, filterSettings string) OutputWriter {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT"))) {
+	case "json":
+		return NewJSONOutput(file, levelSettings, filterSettings)
+	case "auto":
+		if !isterminal.IsTerminal(int(file.Fd())) {
+			return NewJSONOutput(file, levelSettings, filterSettings)
+		}
+	}
+	return NewStandardOutput(file, levelSettings, filterSettings)
+}
+
 type OutputWriter interface {
 	Init()
 	Write(log *Log)
 }
 
+// FilteredWriter is implemented by writers filtering logs by package and level,
+// so the filters can be changed by SetLevel.
+type FilteredWriter interface {
+	LevelFilter() *LevelFilter
+}
+
+// EnablingWriter is implemented by writers deciding by themselves which logs they emit
+// (e.g. SlogWriter by the level of its handler). Runtime.Enabled asks writers without a level filter,
+// so logs none of them emits aren't formatted.
+type EnablingWriter interface {
+	IsEnabled(logger string, level *LogLevel) bool
+}
+
+// WrappingWriter is implemented by writers wrapping another writer (e.g. DedupWriter).
+type WrappingWriter interface {
+	Unwrap() OutputWriter
+}
+
+// fieldsWriter is implemented by writers of the package reading Log.Fields only.
+// Log.Attrs are built for other writers, which may read them.
+type fieldsWriter interface {
+	fieldsOnly()
+}
+
+// readsFields returns whether the writer, and the writers wrapped by it, read Log.Fields only.
+func readsFields(w OutputWriter) bool {
+	for {
+		if _, ok := w.(fieldsWriter); !ok {
+			return false
+		}
+		wrapping, ok := w.(WrappingWriter)
+		if !ok {
+			return true
+		}
+		w = wrapping.Unwrap()
+	}
+}
+
 type Runtime struct {
-	Writers []OutputWriter
+	// writers holds an immutable []*writerEntry, which is replaced on every change (copy-on-write)
+	// so logs can be written without locking.
+	writers atomic.Value
+	// writersVersion is increased on every change of writers, to invalidate levels.
+	writersVersion uint64
+
+	// levels holds *enabledLevels, rebuilt when writers or their level filters change.
+	levels atomic.Value
+
+	// lock serializes the changes of writers.
+	lock sync.Mutex
+	// lastID is the last ID given to a writer entry.
+	lastID uint64
+
+	// async is set if writers run on their own goroutines.
+	async *AsyncOptions
+
+	sampler sampler
+
+	// redaction holds *redactionRules set by SetRedaction.
+	redaction atomic.Value
+
+	// captureCaller is set to 1 if call sites are recorded by default.
+	captureCaller int32
+}
+
+// NewRuntime returns an independent logging pipeline writing to given writers.
+// Use Runtime.New to create loggers bound to the runtime.
+func NewRuntime(writers ...OutputWriter) *Runtime {
+	runtime := &Runtime{}
+	runtime.ReplaceWriters(writers...)
+	return runtime
+}
+
+// New returns a logger bound to the given name, writing to the runtime.
+func (runtime *Runtime) New(name string, opts ...Option) Logger {
+	l := &logger{
+		Name:    name,
+		runtime: runtime,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// SetCaptureCaller sets whether loggers record call sites (file, line and function) of logs by default.
+// It can be overridden by WithCaller option of each logger.
+func (runtime *Runtime) SetCaptureCaller(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&runtime.captureCaller, value)
+}
+
+func (runtime *Runtime) capturesCaller() bool {
+	return atomic.LoadInt32(&runtime.captureCaller) == 1
+}
+
+// writerEntry is a registered writer.
+type writerEntry struct {
+	id     uint64
+	writer OutputWriter
+
+	// output is the writer itself, or its asynchronous wrapper.
+	output OutputWriter
+
+	// readsAttrs is set if the writer may read Log.Attrs, which are built only for such writers.
+	readsAttrs bool
+}
+
+// HookHandle is returned by Hook to remove the hooked writer.
+type HookHandle struct {
+	runtime *Runtime
+	id      uint64
+}
+
+// Unhook removes the writer from the runtime. Logs queued to the writer are written before it returns.
+func (h *HookHandle) Unhook() {
+	h.runtime.lock.Lock()
+	defer h.runtime.lock.Unlock()
+
+	entries := h.runtime.entries()
+	for i, e := range entries {
+		if e.id == h.id {
+			updated := make([]*writerEntry, 0, len(entries)-1)
+			updated = append(updated, entries[:i]...)
+			updated = append(updated, entries[i+1:]...)
+			h.runtime.storeEntries(updated)
+			closeEntries(e)
+			return
+		}
+	}
 }
 
 func (runtime *Runtime) Log(log *Log) {
-	if len(runtime.Writers) == 0 {
+	entries := runtime.entries()
+	for _, e := range entries {
+		if e.readsAttrs {
+			// built before any writer receives the log, as asynchronous writers read it concurrently
+			log.buildAttrs()
+			break
+		}
+	}
+	for _, e := range entries {
+		e.output.Write(log)
+	}
+}
+
+// Writers returns the current writers of the runtime.
+func (runtime *Runtime) Writers() []OutputWriter {
+	entries := runtime.entries()
+	writers := make([]OutputWriter, len(entries))
+	for i, e := range entries {
+		writers[i] = e.writer
+	}
+	return writers
+}
+
+// Hook adds a new writer to the runtime.
+func (runtime *Runtime) Hook(writer OutputWriter) *HookHandle {
+	writer.Init()
+
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+
+	e := runtime.newEntry(writer)
+	entries := runtime.entries()
+	updated := make([]*writerEntry, 0, len(entries)+1)
+	updated = append(updated, entries...)
+	runtime.storeEntries(append(updated, e))
+
+	return &HookHandle{runtime: runtime, id: e.id}
+}
+
+// ReplaceWriters replaces all writers of the runtime with given writers.
+// Logs queued to the previous writers are written before it returns.
+func (runtime *Runtime) ReplaceWriters(writers ...OutputWriter) {
+	for _, w := range writers {
+		w.Init()
+	}
+
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+
+	previous := runtime.entries()
+	updated := make([]*writerEntry, len(writers))
+	for i, w := range writers {
+		updated[i] = runtime.newEntry(w)
+	}
+	runtime.storeEntries(updated)
+	closeEntries(previous...)
+}
+
+// SetLogger replaces the first writer of the runtime.
+func (runtime *Runtime) SetLogger(writer OutputWriter) {
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+
+	entries := runtime.entries()
+	if len(entries) == 0 {
+		runtime.storeEntries([]*writerEntry{runtime.newEntry(writer)})
+		return
+	}
+	updated := append([]*writerEntry(nil), entries...)
+	updated[0] = runtime.newEntry(writer)
+	runtime.storeEntries(updated)
+	closeEntries(entries[0])
+}
+
+// EnableAsync makes every writer of the runtime, including writers hooked later,
+// write logs on its own goroutine through a bounded queue.
+// It should be called before logging, usually on the program startup.
+func (runtime *Runtime) EnableAsync(opts AsyncOptions) {
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+
+	if runtime.async != nil {
 		return
 	}
+	runtime.async = &opts
 
-	// Avoid getting into a loop if there is just one writer
-	if len(runtime.Writers) == 1 {
-		runtime.Writers[0].Write(log)
-	} else {
-		for _, w := range runtime.Writers {
-			w.Write(log)
+	entries := runtime.entries()
+	updated := make([]*writerEntry, len(entries))
+	for i, e := range entries {
+		updated[i] = &writerEntry{
+			id:         e.id,
+			writer:     e.writer,
+			output:     newAsyncWriter(e.writer, opts),
+			readsAttrs: e.readsAttrs,
 		}
 	}
+	runtime.storeEntries(updated)
 }
 
-// Add a new writer
-func Hook(writer OutputWriter) {
-	writer.Init()
-	runtime.Writers = append(runtime.Writers, writer)
+// SetSampling sets the sampling policy of given package pattern (e.g. "db.*", or "*" for all packages),
+// or removes it if the policy is nil.
+func (runtime *Runtime) SetSampling(pattern string, policy *SamplingPolicy) {
+	runtime.sampler.Set(pattern, policy)
+}
+
+// Flush blocks until all writers write their buffered logs, or the context is done.
+func (runtime *Runtime) Flush(ctx context.Context) error {
+	for _, e := range runtime.entries() {
+		if f, ok := e.output.(Flusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of logs dropped by asynchronous writers due to the overflow policy.
+func (runtime *Runtime) Dropped() (dropped uint64) {
+	for _, e := range runtime.entries() {
+		if aw, ok := e.output.(*asyncWriter); ok {
+			dropped += aw.Dropped()
+		}
+	}
+	return
+}
+
+func (runtime *Runtime) entries() []*writerEntry {
+	entries, _ := runtime.writers.Load().([]*writerEntry)
+	return entries
+}
+
+// storeEntries must be called with the lock held.
+func (runtime *Runtime) storeEntries(entries []*writerEntry) {
+	runtime.writers.Store(entries)
+	atomic.AddUint64(&runtime.writersVersion, 1)
+}
+
+// newEntry must be called with the lock held.
+func (runtime *Runtime) newEntry(writer OutputWriter) *writerEntry {
+	runtime.lastID++
+	e := &writerEntry{
+		id:         runtime.lastID,
+		writer:     writer,
+		output:     writer,
+		readsAttrs: !readsFields(writer),
+	}
+	if runtime.async != nil {
+		e.output = newAsyncWriter(writer, *runtime.async)
+	}
+	return e
+}
+
+// closeEntries stops asynchronous wrappers of removed writers.
+func closeEntries(entries ...*writerEntry) {
+	for _, e := range entries {
+		if aw, ok := e.output.(*asyncWriter); ok {
+			aw.Close()
+		}
+	}
+}
+
+// Hook adds a new writer to the default runtime.
+func Hook(writer OutputWriter) *HookHandle {
+	return defaultRuntime.Hook(writer)
+}
+
+// ReplaceWriters replaces all writers of the default runtime.
+func ReplaceWriters(writers ...OutputWriter) {
+	defaultRuntime.ReplaceWriters(writers...)
 }
 
 // Legacy method
 func SetLogger(writer OutputWriter) {
-	runtime.Writers[0] = writer
+	defaultRuntime.SetLogger(writer)
+}
+
+// EnableAsync enables asynchronous writers on the default runtime. See Runtime.EnableAsync.
+func EnableAsync(opts AsyncOptions) {
+	defaultRuntime.EnableAsync(opts)
+}
+
+// SetCaptureCaller sets whether loggers of the default runtime record call sites by default.
+func SetCaptureCaller(enabled bool) {
+	defaultRuntime.SetCaptureCaller(enabled)
+}
+
+// SetSampling sets the sampling policy of the default runtime. See Runtime.SetSampling.
+func SetSampling(pattern string, policy *SamplingPolicy) {
+	defaultRuntime.SetSampling(pattern, policy)
+}
+
+// Flush blocks until the writers of the default runtime write their buffered logs, or the context is done.
+func Flush(ctx context.Context) error {
+	return defaultRuntime.Flush(ctx)
+}
+
+// Dropped returns the number of logs dropped by the default runtime. See Runtime.Dropped.
+func Dropped() uint64 {
+	return defaultRuntime.Dropped()
 }
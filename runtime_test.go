@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRuntimeWriters(t *testing.T) {
+	convey.Convey("Given a runtime", t, func() {
+		rt := &Runtime{}
+
+		convey.Convey("Unhook should remove only the hooked writer", func() {
+			first, second := &memoryWriter{}, &memoryWriter{}
+			rt.Hook(first)
+			handle := rt.Hook(second)
+
+			rt.Log(&Log{Level: Info})
+			handle.Unhook()
+			rt.Log(&Log{Level: Info})
+
+			convey.So(first.Logs(), convey.ShouldHaveLength, 2)
+			convey.So(second.Logs(), convey.ShouldHaveLength, 1)
+			convey.So(rt.Writers(), convey.ShouldHaveLength, 1)
+		})
+
+		convey.Convey("ReplaceWriters should swap all writers", func() {
+			previous, replaced := &memoryWriter{}, &memoryWriter{}
+			rt.Hook(previous)
+			rt.ReplaceWriters(replaced)
+			rt.Log(&Log{Level: Info})
+
+			convey.So(previous.Logs(), convey.ShouldBeEmpty)
+			convey.So(replaced.Logs(), convey.ShouldHaveLength, 1)
+		})
+
+		convey.Convey("Writers can be changed while logging", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						rt.Log(&Log{Level: Info})
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						rt.Hook(&memoryWriter{}).Unhook()
+					}
+				}()
+			}
+			wg.Wait()
+			convey.So(rt.Writers(), convey.ShouldBeEmpty)
+		})
+	})
+}
+
+func TestNewRuntime(t *testing.T) {
+	convey.Convey("Loggers should write only to their runtime", t, func() {
+		first, second := &memoryWriter{}, &memoryWriter{}
+		NewRuntime(first).New("host").Info("hello")
+
+		timer := NewRuntime(second).New("library").Timer()
+		timer.End("done")
+
+		convey.So(first.Logs(), convey.ShouldHaveLength, 1)
+		convey.So(first.Logs()[0].Package, convey.ShouldEqual, "host")
+		convey.So(second.Logs(), convey.ShouldHaveLength, 1)
+		convey.So(second.Logs()[0].Package, convey.ShouldEqual, "library")
+	})
+}
+
+func TestSampling(t *testing.T) {
+	convey.Convey("Given a runtime sampling hot logs", t, func() {
+		mw := &memoryWriter{}
+		rt := NewRuntime(mw)
+		rt.SetSampling("requests", &SamplingPolicy{First: 2, Thereafter: 3, Interval: time.Hour})
+		log := rt.New("requests")
+
+		convey.Convey("It should emit first N, then every Mth log", func() {
+			for i := 0; i < 8; i++ {
+				log.Verbose("Request {}", i)
+			}
+			logs := mw.Logs()
+			convey.So(logs, convey.ShouldHaveLength, 4)
+			convey.So(logs[2].Message, convey.ShouldEqual, "Request 4")
+			convey.So((*logs[2].Attrs)[SuppressedAttrKey], convey.ShouldEqual, 2)
+			convey.So(logs[3].Message, convey.ShouldEqual, "Request 7")
+		})
+
+		convey.Convey("It should count each message template separately", func() {
+			for i := 0; i < 3; i++ {
+				log.Verbose("foo")
+				log.Verbose("bar")
+			}
+			rt.New("other").Verbose("foo")
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 5)
+		})
+
+		convey.Convey("Errors should be counted by the template before the error is added", func() {
+			rt.SetSampling("requests", &SamplingPolicy{First: 1, Interval: time.Hour})
+			for i := 0; i < 3; i++ {
+				log.Error("Request failed", fmt.Errorf("error #%d", i))
+			}
+			convey.So(mw.Logs(), convey.ShouldHaveLength, 1)
+			convey.So(mw.Logs()[0].Message, convey.ShouldEqual, "Request failed: error #0")
+		})
+
+		convey.Convey("The number of counters should be bounded", func() {
+			for i := 0; i < maxSamplingCounters+10; i++ {
+				log.Verbose(fmt.Sprintf("Request #%d", i))
+			}
+			convey.So(rt.sampler.countersLen, convey.ShouldBeLessThanOrEqualTo, maxSamplingCounters)
+			convey.So(mw.Logs(), convey.ShouldHaveLength, maxSamplingCounters+10)
+		})
+	})
+
+	convey.Convey("Sampling policies should be parsed from LOG", t, func() {
+		policies := parseSamplingSettings("*@error,api@verbose~10/100/2s,db~5")
+		convey.So(policies, convey.ShouldHaveLength, 2)
+		convey.So(policies["api"], convey.ShouldResemble, SamplingPolicy{First: 10, Thereafter: 100, Interval: 2 * time.Second})
+		convey.So(policies["db"].First, convey.ShouldEqual, 5)
+		convey.So(parsePackageSettings("api@verbose~10/100", Info.Priority)["api"], convey.ShouldEqual, Verbose.Priority)
+	})
+}
+
+func TestCaptureCaller(t *testing.T) {
+	convey.Convey("Given a runtime capturing callers", t, func() {
+		mw := &memoryWriter{}
+		rt := NewRuntime(mw)
+		rt.SetCaptureCaller(true)
+
+		convey.Convey("It should skip frames of sub-loggers and timers", func() {
+			_, _, line, _ := goruntime.Caller(0)
+			rt.New("app").WithAttrs(Attrs{"a": 1}).Named("sub").Info("hello")
+			rt.New("app").Timer().End("done")
+
+			logs := mw.Logs()
+			convey.So(logs[0].Caller(), convey.ShouldEndWith, "/runtime_test.go:"+strconv.Itoa(line+1))
+			convey.So(logs[0].Function, convey.ShouldEndWith, "TestCaptureCaller.func1.1")
+			convey.So(logs[1].Line, convey.ShouldEqual, line+2)
+		})
+
+		convey.Convey("It should be disabled per logger", func() {
+			rt.New("app", WithCaller(false)).Info("hello")
+			convey.So(mw.Logs()[0].Caller(), convey.ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SuppressedAttrKey is an attribute reporting the number of logs suppressed by sampling
+	// since the last emitted log with the same message template.
+	SuppressedAttrKey = "suppressed"
+
+	defaultSamplingInterval = time.Second
+
+	// maxSamplingCounters bounds the counters of message templates, since messages may be built
+	// dynamically. Expired counters are evicted when the limit is reached.
+	maxSamplingCounters = 4096
+)
+
+// SamplingPolicy logs the first N logs with the same package, level and message template
+// per interval, and every Mth log thereafter.
+type SamplingPolicy struct {
+	// First is the number of logs emitted per interval before sampling starts.
+	First int
+
+	// Thereafter emits every Mth log after First. If it's zero, the rest of the interval is suppressed.
+	Thereafter int
+
+	// Interval defaults to a second.
+	Interval time.Duration
+
+	// MaxLevel is the highest level sampled. Logs with higher level are always emitted.
+	// If it's nil, all levels are sampled.
+	MaxLevel *LogLevel
+}
+
+type sampler struct {
+	// policies holds immutable *samplingPolicies, replaced on every change.
+	policies atomic.Value
+	lock     sync.Mutex
+
+	// counters holds *samplingCounter by samplingKey, and countersLen is the approximate number of them.
+	counters    sync.Map
+	countersLen int32
+}
+
+type samplingPolicies struct {
+	// byPattern holds policies by package pattern (see LevelFilter).
+	byPattern map[string]SamplingPolicy
+	patterns  []string
+}
+
+type samplingKey struct {
+	pkg      string
+	level    *LogLevel
+	template string
+}
+
+type samplingCounter struct {
+	lock       sync.Mutex
+	resetAt    int64
+	count      int
+	suppressed uint64
+}
+
+// Set sets the policy of given package pattern, or removes it if the policy is nil.
+func (s *sampler) Set(pattern string, policy *SamplingPolicy) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	policies := &samplingPolicies{byPattern: map[string]SamplingPolicy{}}
+	if previous := s.load(); previous != nil {
+		for k, v := range previous.byPattern {
+			policies.byPattern[k] = v
+		}
+	}
+	if policy == nil {
+		delete(policies.byPattern, pattern)
+	} else {
+		policies.byPattern[pattern] = *policy
+	}
+	for k := range policies.byPattern {
+		policies.patterns = append(policies.patterns, k)
+	}
+	sortPatterns(policies.patterns)
+	s.policies.Store(policies)
+
+	// counters of the previous policy are no longer valid
+	s.evictLocked(func(*samplingCounter) bool { return true })
+}
+
+// Check returns whether the log should be emitted, and the number of logs suppressed before.
+func (s *sampler) Check(pkg string, level *LogLevel, template string) (emit bool, suppressed uint64) {
+	policies := s.load()
+	if policies == nil || len(policies.patterns) == 0 {
+		return true, 0
+	}
+	pattern, ok := matchPattern(policies.patterns, pkg)
+	if !ok {
+		return true, 0
+	}
+	policy := policies.byPattern[pattern]
+	if policy.MaxLevel != nil && level.Priority > policy.MaxLevel.Priority {
+		return true, 0
+	}
+
+	now := Now()
+	key := samplingKey{pkg: pkg, level: level, template: template}
+	c, ok := s.counters.Load(key)
+	if !ok {
+		if atomic.LoadInt32(&s.countersLen) >= maxSamplingCounters {
+			s.evictExpired(now)
+		}
+		var loaded bool
+		if c, loaded = s.counters.LoadOrStore(key, new(samplingCounter)); !loaded {
+			atomic.AddInt32(&s.countersLen, 1)
+		}
+	}
+	return c.(*samplingCounter).check(policy, now)
+}
+
+// evictExpired removes counters whose interval is over. If most of the counters are still active,
+// all of them are removed, so the number of counters stays bounded.
+func (s *sampler) evictExpired(now int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if atomic.LoadInt32(&s.countersLen) < maxSamplingCounters {
+		return
+	}
+	s.evictLocked(func(c *samplingCounter) bool { return c.expired(now) })
+	if atomic.LoadInt32(&s.countersLen) > maxSamplingCounters/2 {
+		s.evictLocked(func(*samplingCounter) bool { return true })
+	}
+}
+
+// evictLocked removes the counters matching fn. s.lock must be held.
+func (s *sampler) evictLocked(fn func(c *samplingCounter) bool) {
+	s.counters.Range(func(key, c interface{}) bool {
+		if fn(c.(*samplingCounter)) {
+			s.counters.Delete(key)
+			atomic.AddInt32(&s.countersLen, -1)
+		}
+		return true
+	})
+}
+
+func (s *sampler) load() *samplingPolicies {
+	policies, _ := s.policies.Load().(*samplingPolicies)
+	return policies
+}
+
+func (c *samplingCounter) expired(now int64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return now >= c.resetAt
+}
+
+func (c *samplingCounter) check(policy SamplingPolicy, now int64) (bool, uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if now >= c.resetAt {
+		interval := policy.Interval
+		if interval <= 0 {
+			interval = defaultSamplingInterval
+		}
+		c.resetAt = now + int64(interval)
+		c.count = 0
+	}
+	c.count++
+
+	if c.count > policy.First {
+		if policy.Thereafter <= 0 || (c.count-policy.First)%policy.Thereafter != 0 {
+			c.suppressed++
+			return false, 0
+		}
+	}
+	suppressed := c.suppressed
+	c.suppressed = 0
+	return true, suppressed
+}
+
+// Accepts: api~10/100
+//          api@verbose~10/100/1s
+//          *~100/1000,database@timer
+func parseSamplingSettings(input string) map[string]SamplingPolicy {
+	all := map[string]SamplingPolicy{}
+	for _, item := range strings.Split(input, ",") {
+		parsed := strings.SplitN(item, "~", 2)
+		if len(parsed) < 2 {
+			continue
+		}
+		name, _, _ := parsePackageName(parsed[0])
+		if policy, ok := parseSamplingPolicy(parsed[1]); ok {
+			all[name] = policy
+		}
+	}
+	return all
+}
+
+// Accepts: 10
+//          10/100
+//          10/100/1s
+func parseSamplingPolicy(input string) (policy SamplingPolicy, ok bool) {
+	parts := strings.Split(strings.TrimSpace(input), "/")
+	var err error
+	if policy.First, err = strconv.Atoi(parts[0]); err != nil {
+		return policy, false
+	}
+	if len(parts) > 1 {
+		if policy.Thereafter, err = strconv.Atoi(parts[1]); err != nil {
+			return policy, false
+		}
+	}
+	if len(parts) > 2 {
+		if policy.Interval, err = time.ParseDuration(parts[2]); err != nil {
+			return policy, false
+		}
+	}
+	return policy, true
+}
@@ -0,0 +1,275 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	goruntime "runtime"
+	"time"
+)
+
+// SlogHandler is a slog.Handler writing records to a runtime, so libraries logging with log/slog
+// are filtered by LOG settings and written by the writers of the runtime like other loggers.
+// Groups of slog are mapped to groups of fields.
+type SlogHandler struct {
+	runtime *Runtime
+	name    string
+
+	// attrs are the attributes given to WithAttrs, nested in the groups open at the time.
+	attrs Fields
+
+	// groups are the names of the groups given to WithGroup, outermost first.
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing records to the runtime as logs of a logger with the name.
+func (runtime *Runtime) NewSlogHandler(name string) *SlogHandler {
+	return &SlogHandler{runtime: runtime, name: name}
+}
+
+// NewSlogHandler returns a slog.Handler writing to the default runtime. See Runtime.NewSlogHandler.
+func NewSlogHandler(name string) *SlogHandler {
+	return defaultRuntime.NewSlogHandler(name)
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.runtime.Enabled(h.name, levelOfSlog(level))
+}
+
+// Handle implements slog.Handler.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := levelOfSlog(r.Level)
+	emit, suppressed := h.runtime.sampler.Check(h.name, level, r.Message)
+	if !emit {
+		return nil
+	}
+	fields := make(Fields, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, a)
+		return true
+	})
+	var err error
+	if level.Priority >= Error.Priority {
+		err = firstError(fields)
+	}
+
+	args := []interface{}{h.attrs, h.nest(fields)}
+	if ctx != nil {
+		args = withContextAttrs(ctx, args)
+	}
+	// slog messages are plain text, not templates
+	formatted, attrs, displayed := h.runtime.prepare("", r.Message, args, suppressed)
+
+	log := &Log{
+		Package: h.name,
+		Level:   level,
+		Message: formatted,
+		Time:    Now(),
+
+		Fields:          attrs,
+		DisplayedFields: displayed,
+	}
+	if !r.Time.IsZero() {
+		log.Time = r.Time.UnixNano()
+	}
+	if err != nil {
+		log.Err = h.runtime.redactionRules().redactErrorInfo(NewErrorInfo(err))
+	}
+	if r.PC != 0 && h.runtime.capturesCaller() {
+		frame, _ := goruntime.CallersFrames([]uintptr{r.PC}).Next()
+		log.File, log.Line, log.Function = frame.File, frame.Line, frame.Function
+	}
+	h.runtime.Log(log)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields Fields
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	child := *h
+	child.attrs = append(Fields(nil), h.attrs...)
+	for _, f := range h.nest(fields) {
+		child.attrs = child.attrs.set(f)
+	}
+	return &child
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(append([]string(nil), h.groups...), name)
+	return &child
+}
+
+// nest returns the fields nested in the open groups. Groups without fields are omitted.
+func (h *SlogHandler) nest(fields Fields) Fields {
+	if len(fields) == 0 {
+		return nil
+	}
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		fields = Fields{Group(h.groups[i], fields...)}
+	}
+	return fields
+}
+
+// appendSlogAttr appends the attribute as a field. Empty attributes and groups are ignored,
+// and fields of groups without a key are inlined, as slog handlers should.
+func appendSlogAttr(fields Fields, a slog.Attr) Fields {
+	v := a.Value.Resolve()
+	if a.Key == "" && v.Kind() == slog.KindAny && v.Any() == nil {
+		return fields
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return append(fields, String(a.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(a.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, Any(a.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(a.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(a.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(a.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(a.Key, v.Time()))
+	case slog.KindGroup:
+		var group Fields
+		for _, nested := range v.Group() {
+			group = appendSlogAttr(group, nested)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, Group(a.Key, group...))
+	}
+	return append(fields, Any(a.Key, v.Any()))
+}
+
+func firstError(fields Fields) error {
+	for _, f := range fields {
+		if err, ok := f.Value().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// levelOfSlog returns the level of the slog level. Levels below slog.LevelDebug are Verbose.
+func levelOfSlog(level slog.Level) *LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return Verbose
+	case level < slog.LevelInfo:
+		return Debug
+	case level < slog.LevelWarn:
+		return Info
+	case level < slog.LevelError:
+		return Warn
+	}
+	return Error
+}
+
+// slogLevel returns the slog level of the level. Verbose is below slog.LevelDebug,
+// and Fatal is above slog.LevelError.
+func slogLevel(level *LogLevel) slog.Level {
+	switch {
+	case level.Priority < Debug.Priority:
+		return slog.LevelDebug - 4
+	case level.Priority < Info.Priority:
+		return slog.LevelDebug
+	case level.Priority < Warn.Priority:
+		return slog.LevelInfo
+	case level.Priority < Error.Priority:
+		return slog.LevelWarn
+	case level.Priority < Fatal.Priority:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
+
+// SlogWriter writes logs to a slog.Handler, so loggers of this package can be used with log/slog handlers.
+// The logger name is written as the "logger" attribute, and groups of fields as slog groups.
+type SlogWriter struct {
+	Handler slog.Handler
+}
+
+// NewSlogWriter returns a writer writing logs to the handler.
+func NewSlogWriter(handler slog.Handler) *SlogWriter {
+	return &SlogWriter{Handler: handler}
+}
+
+// NewSlogLogger returns a logger with the name writing to the handler, through a runtime of its own.
+func NewSlogLogger(handler slog.Handler, name string) Logger {
+	return NewRuntime(NewSlogWriter(handler)).New(name)
+}
+
+func (sw *SlogWriter) Init() {}
+
+func (sw *SlogWriter) fieldsOnly() {}
+
+// IsEnabled returns whether the handler is enabled for the level, so the runtime skips logs
+// the handler would drop.
+func (sw *SlogWriter) IsEnabled(logger string, level *LogLevel) bool {
+	return sw.Handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (sw *SlogWriter) Write(log *Log) {
+	ctx := context.Background()
+	level := slogLevel(log.Level)
+	if !sw.Handler.Enabled(ctx, level) {
+		// other writers of the runtime may emit the log
+		return
+	}
+	r := slog.NewRecord(time.Unix(0, log.Time), level, log.Message, 0)
+	r.AddAttrs(slog.String("logger", log.Package))
+	fields := log.fields()
+	r.AddAttrs(slogAttrs(fields)...)
+	if log.Level == Timer {
+		if encoded, ok := encodeDuration(time.Duration(log.ElapsedNano)); ok {
+			r.AddAttrs(slog.Any("elapsed", encoded))
+		} else {
+			r.AddAttrs(slog.Duration("elapsed", time.Duration(log.ElapsedNano)))
+		}
+	}
+	if log.Err != nil && fields.index("error") < 0 {
+		r.AddAttrs(slog.String("error", log.Err.Message))
+	}
+	if log.File != "" {
+		r.AddAttrs(slog.Group("source",
+			slog.String("function", log.Function),
+			slog.String("file", log.File),
+			slog.Int("line", log.Line),
+		))
+	}
+	_ = sw.Handler.Handle(ctx, r)
+}
+
+// slogAttrs converts the fields to slog attributes, keeping their types.
+func slogAttrs(fields Fields) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		if group, ok := f.group(); ok {
+			attrs = append(attrs, slog.Attr{Key: f.Key, Value: slog.GroupValue(slogAttrs(group)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(f.Key, f.Value()))
+	}
+	return attrs
+}
@@ -0,0 +1,97 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlogHandler(t *testing.T) {
+	convey.Convey("Given a slog logger backed by a runtime", t, func() {
+		w := &filteredMemoryWriter{filter: NewLevelFilter(map[string]LogPriority{"*": Info.Priority})}
+		l := slog.New(NewRuntime(w).NewSlogHandler("lib"))
+
+		convey.Convey("Records should be written as logs with typed attributes", func() {
+			l.Warn("cache {miss}", "key", "a", "size", 3, slog.Duration("ttl", time.Second))
+			log := w.Logs()[0]
+			convey.So(log.Package, convey.ShouldEqual, "lib")
+			convey.So(log.Level, convey.ShouldEqual, Warn)
+			convey.So(log.Message, convey.ShouldEqual, "cache {miss}")
+			convey.So(log.Fields, convey.ShouldResemble, Fields{String("key", "a"), Int64("size", 3), Duration("ttl", time.Second)})
+		})
+
+		convey.Convey("Registered encoders should apply to typed attributes", func() {
+			previous := loadEncoders()
+			defer encoders.Store(previous)
+			RegisterEncoder(time.Duration(0), func(v interface{}) interface{} { return "D" })
+
+			l.Info("cached", slog.Duration("ttl", time.Second))
+			convey.So((*w.Logs()[0].Attrs)["ttl"], convey.ShouldEqual, "D")
+		})
+
+		convey.Convey("Levels should be filtered by the runtime", func() {
+			convey.So(l.Enabled(context.Background(), slog.LevelDebug), convey.ShouldBeFalse)
+			l.Debug("hidden")
+			convey.So(w.Logs(), convey.ShouldBeEmpty)
+		})
+
+		convey.Convey("Groups and attributes of the handler should be mapped to groups", func() {
+			l.With("app", "x").WithGroup("http").With("method", "GET").WithGroup("empty").Info("handled",
+				slog.Group("", "inlined", true), slog.Group("none"))
+			l.With("app", "x").WithGroup("http").Info("handled", "status", 200)
+
+			logs := w.Logs()
+			convey.So(*logs[0].Attrs, convey.ShouldResemble, Attrs{
+				"app":  "x",
+				"http": Attrs{"method": "GET", "empty": Attrs{"inlined": true}},
+			})
+			convey.So(*logs[1].Attrs, convey.ShouldResemble, Attrs{"app": "x", "http": Attrs{"status": int64(200)}})
+		})
+
+		convey.Convey("Errors should be captured by error logs", func() {
+			l.ErrorContext(ContextWithAttrs(context.Background(), Attrs{"request_id": "r1"}), "failed", "err", errors.New("boom"))
+			log := w.Logs()[0]
+			convey.So(log.Err.Message, convey.ShouldEqual, "boom")
+			convey.So((*log.Attrs)["request_id"], convey.ShouldEqual, "r1")
+		})
+	})
+}
+
+func TestSlogWriter(t *testing.T) {
+	convey.Convey("Given a logger writing to a slog handler", t, func() {
+		buf := new(bytes.Buffer)
+		l := NewSlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}), "app")
+
+		convey.Convey("Logs should be written as records", func() {
+			l.WithGroup("http").Warn("responded {http.status}", Attrs{"status": 500, "path": "/"})
+
+			var out map[string]interface{}
+			convey.So(json.Unmarshal(buf.Bytes(), &out), convey.ShouldBeNil)
+			convey.So(out["level"], convey.ShouldEqual, "WARN")
+			convey.So(out["msg"], convey.ShouldEqual, "responded 500")
+			convey.So(out["logger"], convey.ShouldEqual, "app")
+			convey.So(out["http"], convey.ShouldResemble, map[string]interface{}{"status": 500.0, "path": "/"})
+		})
+
+		convey.Convey("Logs disabled by the handler should not be prepared nor written", func() {
+			evaluated := false
+			l.Debug("hidden {}", Lazy(func() interface{} {
+				evaluated = true
+				return 1
+			}))
+			convey.So(l.Enabled(Debug), convey.ShouldBeFalse)
+			convey.So(l.Enabled(Info), convey.ShouldBeTrue)
+			convey.So(evaluated, convey.ShouldBeFalse)
+			convey.So(buf.Len(), convey.ShouldEqual, 0)
+		})
+	})
+}
@@ -1,43 +1,53 @@
 package logger
 
 import (
-	"encoding/hex"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	isterminal "github.com/azer/is-terminal"
 )
 
+// DefaultPriorityKeys is used by NewStandardOutput.
+var DefaultPriorityKeys = []string{"request_id"}
+
 func NewStandardOutput(file *os.File, levelSettings, filterSettings string) StandardWriter {
-	var writer = StandardWriter{
+	return StandardWriter{
 		ColorsEnabled: isterminal.IsTerminal(int(file.Fd())),
 		Target:        file,
+		Filter:        parseOutputSettings(levelSettings, filterSettings),
+		PriorityKeys:  DefaultPriorityKeys,
 	}
-
-	if os.Getenv("LOG_LEVEL") != "" {
-		levelSettings = os.Getenv("LOG_LEVEL")
-	}
-	if os.Getenv("LOG") != "" {
-		filterSettings = os.Getenv("LOG")
-	} else if filterSettings == "" {
-		filterSettings = "*"
-	}
-	defaultOutputSettings := parseVerbosityLevel(levelSettings)
-	writer.Verbosities = parsePackageSettings(filterSettings, defaultOutputSettings)
-
-	return writer
 }
 
 type StandardWriter struct {
 	ColorsEnabled bool
 	Target        *os.File
-	Verbosities   map[string]LogPriority
+	Filter        *LevelFilter
+
+	// Verbosities are the minimum priorities by package name, or "*" for the others.
+	// It's used only if Filter is nil.
+	//
+	// Deprecated: Use Filter, which supports patterns and SetLevel.
+	Verbosities map[string]LogPriority
+
+	// PriorityKeys are attributes printed first, in the order. The others follow in the order
+	// given to the logger, and then the defaults of sub-loggers and contexts.
+	// Attributes in groups are printed with dotted keys (e.g. "http.status").
+	PriorityKeys []string
 }
 
 func (sw StandardWriter) Init() {}
 
+func (sw StandardWriter) fieldsOnly() {}
+
+func (sw StandardWriter) LevelFilter() *LevelFilter {
+	return sw.Filter
+}
+
 func (sw StandardWriter) Write(log *Log) {
 	if sw.IsEnabled(log.Package, log.Level) {
 		fmt.Fprintln(sw.Target, sw.Format(log))
@@ -45,20 +55,20 @@ func (sw StandardWriter) Write(log *Log) {
 }
 
 func (sw *StandardWriter) IsEnabled(logger string, level *LogLevel) bool {
-	verbosity := sw.LogVerbosityOfPackage(logger)
-	return level.Priority >= verbosity
+	return level.Priority >= sw.LogVerbosityOfPackage(logger)
 }
 
 func (sw *StandardWriter) LogVerbosityOfPackage(p string) LogPriority {
-	if settings, ok := sw.Verbosities[p]; ok {
-		return settings
-	}
-
-	// If there is a "*" (Select all) setting, return that
-	if settings, ok := sw.Verbosities["*"]; ok {
-		return settings
+	if sw.Filter == nil && sw.Verbosities != nil {
+		if priority, ok := sw.Verbosities[p]; ok {
+			return priority
+		}
+		if priority, ok := sw.Verbosities["*"]; ok {
+			return priority
+		}
+		return mutePriority
 	}
-	return mutePriority
+	return sw.Filter.Priority(p)
 }
 
 func (sw *StandardWriter) Format(log *Log) string {
@@ -69,35 +79,70 @@ func (sw *StandardWriter) Format(log *Log) string {
 			msg := fmt.Sprintf("%s │ %s%s: %s%s", log.Level.Symbol(), log.Package, sw.PrettyLabelExt(log), line, sw.PrettyAttrs(log))
 			output += fmt.Sprintf(
 				"%s %s",
-				sw.colored(dim, time.Now().Format("2006-01-02 15:04:05.000")),
+				sw.colored(dim, time.Unix(0, log.Time).Format("2006-01-02 15:04:05.000")),
 				sw.colored(log.Level.Color, msg),
 			)
 		} else {
-			output += fmt.Sprintf("\n%s %s", sw.colored(dim, strings.Repeat(" ", 24)), sw.colored(log.Level.Color, " │ "+line))
+			output += sw.continuationLine(log, line)
+		}
+	}
+	if log.Err != nil {
+		for _, frame := range log.Err.Stack {
+			output += sw.continuationLine(log, "    "+frame)
 		}
 	}
 	return output
 }
 
-func (sw *StandardWriter) PrettyAttrs(log *Log) string {
-	if *log.DisplayedAttrs == nil {
-		return ""
-	}
+func (sw *StandardWriter) continuationLine(log *Log, line string) string {
+	return fmt.Sprintf("\n%s %s", sw.colored(dim, strings.Repeat(" ", 24)), sw.colored(log.Level.Color, " │ "+line))
+}
 
-	result := ""
-	for key, val := range *log.DisplayedAttrs {
+func (sw *StandardWriter) PrettyAttrs(log *Log) string {
+	var b strings.Builder
+	write := func(f Field) {
+		val := f.Value()
 		if byteval, ok := val.([]byte); ok {
-			val = hex.EncodeToString(byteval)
+			val = loadEncoders().encodeBytes(byteval)
+		}
+		b.WriteByte(' ')
+		b.WriteString(quoteAttr(f.Key))
+		b.WriteByte('=')
+		b.WriteString(quoteAttr(fmt.Sprintf("%v", val)))
+	}
+	attrs := log.displayedFields().Flatten()
+	for _, key := range sw.PriorityKeys {
+		if i := attrs.index(key); i >= 0 {
+			write(attrs[i])
+		}
+	}
+	for _, f := range attrs {
+		if !containsString(sw.PriorityKeys, f.Key) {
+			write(f)
 		}
-		result = fmt.Sprintf("%s %s=%v", result, key, val)
 	}
 
-	if log.Level == Fatal {
+	result := b.String()
+	if log.Level.Priority == Fatal.Priority {
 		result = sw.colored(Red, result)
 	}
 	return result
 }
 
+// quoteAttr quotes the text if it's empty, or contains spaces, "=", quotes or control characters,
+// so "key=value" pairs can be split unambiguously.
+func quoteAttr(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
 func (sw *StandardWriter) PrettyLabel(log *Log) string {
 	return fmt.Sprintf("%s%s │ %s%s:%s",
 		log.Level.Color,
@@ -108,10 +153,18 @@ func (sw *StandardWriter) PrettyLabel(log *Log) string {
 }
 
 func (sw *StandardWriter) PrettyLabelExt(log *Log) string {
-	if log.Level == Timer {
-		return fmt.Sprintf("(%v)", time.Duration(log.ElapsedNano))
+	ext := ""
+	if log.Level.Priority == Timer.Priority {
+		var elapsed interface{} = time.Duration(log.ElapsedNano)
+		if encoded, ok := encodeDuration(time.Duration(log.ElapsedNano)); ok {
+			elapsed = encoded
+		}
+		ext = fmt.Sprintf("(%v)", elapsed)
+	}
+	if caller := log.Caller(); caller != "" {
+		ext += " " + caller
 	}
-	return ""
+	return ext
 }
 
 func (sw *StandardWriter) colored(color, text string) string {
@@ -121,17 +174,33 @@ func (sw *StandardWriter) colored(color, text string) string {
 	return text
 }
 
+// parseOutputSettings builds a package filter from given settings,
+// which are overridden by LOG_LEVEL and LOG environment variables.
+func parseOutputSettings(levelSettings, filterSettings string) *LevelFilter {
+	if os.Getenv("LOG_LEVEL") != "" {
+		levelSettings = os.Getenv("LOG_LEVEL")
+	}
+	if os.Getenv("LOG") != "" {
+		filterSettings = os.Getenv("LOG")
+	} else if filterSettings == "" {
+		filterSettings = "*"
+	}
+	defaultOutputSettings := parseVerbosityLevel(levelSettings)
+	return NewLevelFilter(parsePackageSettings(filterSettings, defaultOutputSettings))
+}
+
 // Accepts: foo,bar,qux@timer
 //          *
 //          *@error
 //          *@error,database@timer
+//          db.*@debug,*.cache@mute
 func parsePackageSettings(input string, defaultVerbosity LogPriority) map[string]LogPriority {
 	all := map[string]LogPriority{}
 	items := strings.Split(input, ",")
 
 	for _, item := range items {
-		name, verbosity := parsePackageName(item)
-		if verbosity == -1 {
+		name, verbosity, ok := parsePackageName(item)
+		if !ok {
 			verbosity = defaultVerbosity
 		}
 		all[name] = verbosity
@@ -142,25 +211,24 @@ func parsePackageSettings(input string, defaultVerbosity LogPriority) map[string
 // Accepts: users
 //          database@timer
 //          server@error
-func parsePackageName(input string) (string, LogPriority) {
+func parsePackageName(input string) (name string, verbosity LogPriority, ok bool) {
+	// strip sampling settings (see parseSamplingSettings)
+	input = strings.SplitN(input, "~", 2)[0]
+
 	parsed := strings.Split(input, "@")
-	name := strings.TrimSpace(parsed[0])
+	name = strings.TrimSpace(parsed[0])
 
 	if len(parsed) > 1 {
-		return name, parseVerbosityLevel(parsed[1])
+		return name, parseVerbosityLevel(parsed[1]), true
 	}
-	return name, -1
+	return name, 0, false
 }
 
 func parseVerbosityLevel(val string) LogPriority {
-	val = strings.ToUpper(strings.TrimSpace(val))
-	if lvl, ok := logLevelNameMap[val]; !ok {
-		if val == "MUTE" {
-			return Fatal.Priority
-		}
+	lvl, err := ParseLevel(val)
+	if err != nil {
 		// "*" or unknown level: verbose
 		return 0
-	} else {
-		return lvl.Priority
 	}
+	return lvl.Priority
 }
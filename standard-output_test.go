@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrettyAttrs(t *testing.T) {
+	convey.Convey("Given a standard writer", t, func() {
+		sw := &StandardWriter{PriorityKeys: []string{"request_id", "user"}}
+		mw := &memoryWriter{}
+		l := NewRuntime(mw).New("api")
+
+		convey.Convey("It should print attributes in a stable order", func() {
+			ctx := ContextWithAttrs(context.Background(), Attrs{"request_id": "r1"})
+			l.WithAttrs(Attrs{"shard": 2, "region": "kr"}).InfoCtx(ctx, "hi", Int("status", 200), Attrs{"user": "alice", "b": 1, "a": 2})
+
+			convey.So(sw.PrettyAttrs(mw.Logs()[0]), convey.ShouldEqual, " request_id=r1 user=alice status=200 a=2 b=1 region=kr shard=2")
+		})
+
+		convey.Convey("It should quote values with spaces or equal signs", func() {
+			l.Info("hi", Fields{String("path", "/a b"), String("query", "a=b"), String("empty", ""), String("q", `"x"`), String("ok", "plain")})
+
+			convey.So(sw.PrettyAttrs(mw.Logs()[0]), convey.ShouldEqual, ` path="/a b" query="a=b" empty="" q="\"x\"" ok=plain`)
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"log"
+	"strings"
+)
+
+// RedirectStdLog makes the standard log package write to the logger, with given level by default.
+// Messages starting with a level like "warning:" or "error:" are logged with that level.
+// The date, time and file written by the standard logger are removed from messages, and the file
+// is kept in the "caller" attribute, so flags and the prefix of the standard logger should be set
+// before calling it. It returns a function restoring the previous output.
+func RedirectStdLog(l Logger, level *LogLevel) (restore func()) {
+	previous := log.Writer()
+	log.SetOutput(&stdLogWriter{logger: l, level: level, flags: log.Flags(), prefix: log.Prefix()})
+	return func() {
+		log.SetOutput(previous)
+	}
+}
+
+// stdLogWriter is the output of the standard logger, which writes an entry per Write call.
+type stdLogWriter struct {
+	logger Logger
+	level  *LogLevel
+
+	// flags and prefix of the standard logger are read once, since the standard logger holds
+	// its lock while writing on Go versions before 1.21.
+	flags  int
+	prefix string
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	message, caller := parseStdLog(strings.TrimRight(string(p), "\r\n"), w.flags, w.prefix)
+	level, message := detectLevel(message, w.level)
+
+	var args []interface{}
+	if caller != "" {
+		args = append(args, String("caller", caller))
+	}
+	logText(w.logger, level, message, args)
+	return len(p), nil
+}
+
+// parseStdLog removes the header written by the standard logger with the flags and the prefix
+// (e.g. "prefix 2009/01/23 01:23:23 file.go:23: message"), and returns the message and the file.
+func parseStdLog(entry string, flags int, prefix string) (message, caller string) {
+	if flags&log.Lmsgprefix == 0 {
+		entry = strings.TrimPrefix(entry, prefix)
+	}
+	if flags&log.Ldate != 0 {
+		entry = skipField(entry)
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		entry = skipField(entry)
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if i := strings.Index(entry, ": "); i >= 0 {
+			caller, entry = entry[:i], entry[i+2:]
+		}
+	}
+	if flags&log.Lmsgprefix != 0 {
+		entry = strings.TrimPrefix(entry, prefix)
+	}
+	return entry, caller
+}
+
+// skipField removes the text up to the first space, and the space.
+func skipField(s string) string {
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// levelAliases are prefixes of messages which aren't level names.
+var levelAliases = map[string]*LogLevel{
+	"WARNING": Warn,
+	"ERR":     Error,
+}
+
+// detectLevel returns the level named by the prefix of the message (e.g. "warning: disk is full"
+// or "[ERROR] failed"), and the message without the prefix. If the message has no such prefix,
+// the message and the default level are returned. Fatal prefixes are logged as errors,
+// so they don't stop the program.
+func detectLevel(message string, defaultLevel *LogLevel) (*LogLevel, string) {
+	var name, rest string
+	if strings.HasPrefix(message, "[") {
+		end := strings.IndexByte(message, ']')
+		if end < 0 {
+			return defaultLevel, message
+		}
+		name, rest = message[1:end], message[end+1:]
+	} else {
+		end := strings.IndexByte(message, ':')
+		if end < 0 || strings.IndexByte(message[:end], ' ') >= 0 {
+			return defaultLevel, message
+		}
+		name, rest = message[:end], message[end+1:]
+	}
+
+	level, ok := levelAliases[strings.ToUpper(name)]
+	if !ok {
+		var err error
+		if level, err = ParseLevel(name); err != nil {
+			return defaultLevel, message
+		}
+	}
+	if level.Priority >= Fatal.Priority {
+		level = Error
+	}
+	return level, strings.TrimLeft(rest, " ")
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	convey.Convey("Given the standard logger redirected to a logger", t, func() {
+		output, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+		defer func() {
+			log.SetOutput(output)
+			log.SetFlags(flags)
+			log.SetPrefix(prefix)
+		}()
+		original := new(bytes.Buffer)
+		log.SetOutput(original)
+
+		w := &memoryWriter{}
+		l := NewRuntime(w).New("stdlog")
+
+		convey.Convey("Entries should be logged without the header", func() {
+			log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+			log.SetPrefix("app ")
+			defer RedirectStdLog(l, Info)()
+			log.Printf("listening on {%d}", 80)
+
+			logs := w.Logs()
+			convey.So(logs[0].Level, convey.ShouldEqual, Info)
+			convey.So(logs[0].Message, convey.ShouldEqual, "listening on {80}")
+			convey.So((*logs[0].Attrs)["caller"], convey.ShouldStartWith, "stdlog_test.go:")
+		})
+
+		convey.Convey("Level prefixes should be detected", func() {
+			log.SetFlags(log.Lmsgprefix)
+			log.SetPrefix("app: ")
+			defer RedirectStdLog(l, Info)()
+			log.Print("warning: disk is full")
+			log.Print("error: failed\nwith details")
+			log.Print("http: TLS handshake error")
+
+			logs := w.Logs()
+			convey.So(logs[0].Level, convey.ShouldEqual, Warn)
+			convey.So(logs[0].Message, convey.ShouldEqual, "disk is full")
+			convey.So(logs[1].Level, convey.ShouldEqual, Error)
+			convey.So(logs[1].Message, convey.ShouldEqual, "failed\nwith details")
+			convey.So(logs[2].Level, convey.ShouldEqual, Info)
+			convey.So(logs[2].Message, convey.ShouldEqual, "http: TLS handshake error")
+		})
+
+		convey.Convey("Restoring should bring back the previous output", func() {
+			RedirectStdLog(l, Info)()
+			log.SetFlags(0)
+			log.Print("restored")
+			convey.So(original.String(), convey.ShouldEqual, "restored\n")
+			convey.So(w.Logs(), convey.ShouldBeEmpty)
+		})
+	})
+}
+
+func TestDetectLevel(t *testing.T) {
+	convey.Convey("Levels should be detected from prefixes", t, func() {
+		cases := map[string]*LogLevel{
+			"warn: a":    Warn,
+			"WARNING: a": Warn,
+			"[ERROR] a":  Error,
+			"err: a":     Error,
+			"debug: a":   Debug,
+			"fatal: a":   Error,
+		}
+		for message, expected := range cases {
+			level, rest := detectLevel(message, Info)
+			convey.So(level, convey.ShouldEqual, expected)
+			convey.So(rest, convey.ShouldEqual, "a")
+		}
+
+		level, rest := detectLevel("[unknown] a", Verbose)
+		convey.So(level, convey.ShouldEqual, Verbose)
+		convey.So(rest, convey.ShouldEqual, "[unknown] a")
+	})
+}
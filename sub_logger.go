@@ -1,79 +1,222 @@
 package logger
 
+import (
+	"context"
+	"io"
+)
+
 type subLogger struct {
 	parent       Logger
 	defaultAttrs Attrs
+
+	// defaults are defaultAttrs converted to fields, appended to arguments of every log.
+	defaults defaultFields
+
+	// group nests attributes given to the logger (and its sub-loggers) under the name.
+	group string
+}
+
+func newSubLogger(parent Logger, attrs Attrs) *subLogger {
+	return &subLogger{
+		parent:       parent,
+		defaultAttrs: attrs,
+		defaults:     defaultFields(Fields(nil).setAttrs(attrs)),
+	}
+}
+
+func newGroupLogger(parent Logger, name string) *subLogger {
+	return &subLogger{
+		parent: parent,
+		group:  name,
+	}
 }
 
 func (s *subLogger) mergeWithDefaultAttrs(args []interface{}) []interface{} {
-	attrs := Attrs{}
-	if len(args) > 0 {
-		lastIndex := len(args) - 1
-		if att, ok := args[lastIndex].(Attrs); ok {
-			attrs = att
-			args = args[:lastIndex]
+	if s.group != "" {
+		return s.groupAttrs(args)
+	}
+	merged := make([]interface{}, len(args), len(args)+1)
+	copy(merged, args)
+	return append(merged, s.defaults)
+}
+
+// groupAttrs merges attributes in the arguments into a group, leaving positional arguments as is.
+func (s *subLogger) groupAttrs(args []interface{}) []interface{} {
+	merged := make([]interface{}, 0, len(args)+1)
+	var attrs []interface{}
+	for _, arg := range args {
+		switch arg.(type) {
+		case Attrs, Field, Fields, defaultFields:
+			attrs = append(attrs, arg)
+		default:
+			merged = append(merged, arg)
 		}
 	}
-	mergedAttrs := s.defaultAttrs.Merge(attrs)
-	return append(args, mergedAttrs)
+	if len(attrs) == 0 {
+		return merged
+	}
+	return append(merged, Group(s.group, collectFields(attrs)...))
 }
 
 func (s *subLogger) Log(level *LogLevel, message string, args []interface{}) {
+	if !s.parent.Enabled(level) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(args)
 	s.parent.Log(level, message, vv)
 }
 
+func (s *subLogger) logText(level *LogLevel, text string, args []interface{}) {
+	if !s.parent.Enabled(level) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(args)
+	logText(s.parent, level, text, vv)
+}
+
 func (s *subLogger) Verbose(msg string, v ...interface{}) {
+	if !s.parent.Enabled(Verbose) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
 	s.parent.Verbose(msg, vv...)
 }
 
 func (s *subLogger) Debug(msg string, v ...interface{}) {
+	if !s.parent.Enabled(Debug) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
 	s.parent.Debug(msg, vv...)
 }
 
 func (s *subLogger) Info(msg string, v ...interface{}) {
+	if !s.parent.Enabled(Info) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
 	s.parent.Info(msg, vv...)
 }
 
 func (s *subLogger) Warn(msg string, v ...interface{}) {
+	if !s.parent.Enabled(Warn) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
 	s.parent.Warn(msg, vv...)
 }
 
 func (s *subLogger) Error(msg string, v ...interface{}) {
+	if !s.parent.Enabled(Error) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
 	s.parent.Error(msg, vv...)
 }
 
+func (s *subLogger) LogCtx(ctx context.Context, level *LogLevel, message string, args []interface{}) {
+	if !s.parent.Enabled(level) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(args)
+	s.parent.LogCtx(ctx, level, message, vv)
+}
+
+func (s *subLogger) VerboseCtx(ctx context.Context, msg string, v ...interface{}) {
+	if !s.parent.Enabled(Verbose) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(v)
+	s.parent.VerboseCtx(ctx, msg, vv...)
+}
+
+func (s *subLogger) DebugCtx(ctx context.Context, msg string, v ...interface{}) {
+	if !s.parent.Enabled(Debug) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(v)
+	s.parent.DebugCtx(ctx, msg, vv...)
+}
+
+func (s *subLogger) InfoCtx(ctx context.Context, msg string, v ...interface{}) {
+	if !s.parent.Enabled(Info) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(v)
+	s.parent.InfoCtx(ctx, msg, vv...)
+}
+
+func (s *subLogger) WarnCtx(ctx context.Context, msg string, v ...interface{}) {
+	if !s.parent.Enabled(Warn) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(v)
+	s.parent.WarnCtx(ctx, msg, vv...)
+}
+
+func (s *subLogger) ErrorCtx(ctx context.Context, msg string, v ...interface{}) {
+	if !s.parent.Enabled(Error) {
+		return
+	}
+	vv := s.mergeWithDefaultAttrs(v)
+	s.parent.ErrorCtx(ctx, msg, vv...)
+}
+
 func (s *subLogger) Timer() *Log {
 	// TODO: implement
 	return s.parent.Timer()
 }
 
+func (s *subLogger) Enabled(level *LogLevel) bool {
+	return s.parent.Enabled(level)
+}
+
 func (s *subLogger) Fatal(v ...interface{}) {
+	if !s.parent.Enabled(Fatal) {
+		// the parent still exits
+		s.parent.Fatal(v...)
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
-	s.Fatal(vv...)
+	s.parent.Fatal(vv...)
 }
 
 func (s *subLogger) Wtf(v ...interface{}) {
+	if !s.parent.Enabled(Fatal) {
+		return
+	}
 	vv := s.mergeWithDefaultAttrs(v)
-	s.Wtf(vv...)
+	s.parent.Wtf(vv...)
 }
 
 func (s *subLogger) Recover(optionalContext ...Attrs) *PanicError {
 	if len(optionalContext) > 0 {
 		mergedAttrs := s.defaultAttrs.Merge(optionalContext[0])
+		if s.group != "" {
+			mergedAttrs = Attrs{s.group: mergedAttrs}
+		}
 		return s.parent.Recover(mergedAttrs)
 	}
 	return s.parent.Recover()
 }
 
 func (s *subLogger) WithAttrs(attrs Attrs) Logger {
+	return newSubLogger(s, attrs)
+}
+
+func (s *subLogger) WithGroup(name string) Logger {
+	return newGroupLogger(s, name)
+}
+
+func (s *subLogger) Writer(level *LogLevel, opts ...WriterOption) io.WriteCloser {
+	return newLineWriter(s, level, opts)
+}
+
+func (s *subLogger) Named(name string) Logger {
 	return &subLogger{
-		parent:       s,
-		defaultAttrs: attrs,
+		parent:       s.parent.Named(name),
+		defaultAttrs: s.defaultAttrs,
+		defaults:     s.defaults,
+		group:        s.group,
 	}
 }
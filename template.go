@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxCachedTemplates bounds the template cache, since messages may be built dynamically
+// (e.g. with fmt.Sprintf). The least recently used template is evicted when it's full.
+const maxCachedTemplates = 4096
+
+var (
+	templateCache = newTemplateLRU(maxCachedTemplates)
+
+	bufferPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+)
+
+// template is a pre-parsed format string. It's immutable and shared between goroutines.
+type template struct {
+	segments []templateSegment
+
+	// literal is the formatted string if the template has no replacement field.
+	literal  string
+	isStatic bool
+}
+
+// templateSegment is a literal text followed by an optional replacement field.
+type templateSegment struct {
+	literal  string
+	field    placeholder
+	hasField bool
+}
+
+// lookupTemplate returns the compiled template of the format string, from the cache if possible.
+func lookupTemplate(format string) *template {
+	if t, ok := templateCache.get(format); ok {
+		return t
+	}
+	t := compileTemplate(format)
+	templateCache.add(format, t)
+	return t
+}
+
+// templateLRU holds compiled templates by format string, up to the size.
+type templateLRU struct {
+	lock    sync.Mutex
+	size    int
+	entries map[string]*list.Element
+
+	// order holds *templateEntry, the most recently used first.
+	order *list.List
+}
+
+type templateEntry struct {
+	format   string
+	template *template
+}
+
+func newTemplateLRU(size int) *templateLRU {
+	return &templateLRU{size: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+func (c *templateLRU) get(format string) (*template, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[format]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*templateEntry).template, true
+}
+
+func (c *templateLRU) add(format string, t *template) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.entries[format]; ok {
+		return
+	}
+	c.entries[format] = c.order.PushFront(&templateEntry{format: format, template: t})
+	if c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(*templateEntry)
+		delete(c.entries, oldest.format)
+	}
+}
+
+// compileTemplate parses the format string. See Format for the syntax.
+func compileTemplate(format string) *template {
+	t := &template{}
+	autoIndex := 0
+
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '}' {
+			if i+1 < len(format) && format[i+1] == '}' {
+				i++
+			}
+			literal.WriteByte(c)
+			continue
+		}
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '{' {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := strings.IndexAny(format[i+1:], "{}")
+		if end < 0 || format[i+1+end] != '}' {
+			literal.WriteByte(c)
+			continue
+		}
+		p, ok := parsePlaceholder(format[i+1:i+1+end], &autoIndex)
+		if !ok {
+			literal.WriteByte(c)
+			continue
+		}
+		i += end + 1
+
+		t.segments = append(t.segments, templateSegment{literal: literal.String(), field: p, hasField: true})
+		literal.Reset()
+	}
+	if literal.Len() > 0 || len(t.segments) == 0 {
+		t.segments = append(t.segments, templateSegment{literal: literal.String()})
+	}
+	if len(t.segments) == 1 && !t.segments[0].hasField {
+		t.literal = t.segments[0].literal
+		t.isStatic = true
+	}
+	return t
+}
+
+// attrSource holds the attributes a template is rendered with: fields of a log, or Attrs given to Format.
+// It's passed by value, so rendering doesn't allocate for it.
+type attrSource struct {
+	fields Fields
+	attrs  Attrs
+}
+
+// lookup returns the attribute with the key. Values of Attrs are returned as is,
+// so nested Attrs are accessed like other maps.
+func (src attrSource) lookup(key string) (Field, bool) {
+	if src.attrs != nil {
+		value, ok := src.attrs[key]
+		return Field{Key: key, value: value}, ok
+	}
+	if i := src.fields.index(key); i >= 0 {
+		return src.fields[i], true
+	}
+	return Field{}, false
+}
+
+// render formats the template with the attributes, and returns the keys of the attributes used.
+func (t *template) render(src attrSource, used []string) (string, []string) {
+	if t.isStatic {
+		return t.literal, used
+	}
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	defer bufferPool.Put(b)
+
+	for _, seg := range t.segments {
+		b.WriteString(seg.literal)
+		if !seg.hasField {
+			continue
+		}
+		field, key, ok := seg.field.resolve(src)
+		if !ok {
+			b.WriteString(seg.field.missing())
+			continue
+		}
+		used = append(used, key)
+		if seg.field.conversion != 0 || seg.field.spec != defaultFormatSpec {
+			b.WriteString(seg.field.render(field.Value()))
+			continue
+		}
+		field.appendValue(b)
+	}
+	return b.String(), used
+}
+
+// writeValue writes the value with "%v" format. Common types are written without going through fmt.
+func writeValue(b *bytes.Buffer, value interface{}) {
+	var scratch [24]byte
+	switch v := value.(type) {
+	case string:
+		b.WriteString(v)
+	case int:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case int32:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case uint:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		b.Write(strconv.AppendUint(scratch[:0], v, 10))
+	case bool:
+		b.Write(strconv.AppendBool(scratch[:0], v))
+	default:
+		fmt.Fprint(b, value)
+	}
+}
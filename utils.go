@@ -6,7 +6,14 @@ import (
 )
 
 // Inspect dumps given struct content to the Logger, with verbose message.
+// Values implementing LogValuer are dumped by their log representation.
 func Inspect(log Logger, prefixMsg string, structVal interface{}) {
+	if _, ok := structVal.(LogValuer); ok {
+		structVal = resolveValue(structVal)
+		if fields, ok := structVal.(Fields); ok {
+			structVal = fields.Attrs()
+		}
+	}
 	raw, err := yaml.Marshal(structVal)
 	if err != nil {
 		log.Warn("Unable to inspect struct (message was {})", err, prefixMsg)
@@ -20,5 +27,21 @@ func Inspect(log Logger, prefixMsg string, structVal interface{}) {
 		}
 		logs += "\n" + strings.Repeat(" ", 25) + " │   " + line
 	}
-	log.Verbose(logs)
+	logText(log, Verbose, logs, nil)
+}
+
+// escapeBraces escapes brackets in the text, so it can be used in a format string as is.
+func escapeBraces(text string) string {
+	return braceEscaper.Replace(text)
+}
+
+var braceEscaper = strings.NewReplacer("{", "{{", "}", "}}")
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
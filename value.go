@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+)
+
+// LogValuer is implemented by types controlling their log representation, e.g. to log a compact form
+// or to hide secrets. LogValue may return Attrs or Fields to log a group. Like Lazy, it's called once
+// when the log is emitted, and the message and every writer use the result.
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// maxLogValueDepth limits evaluation of values returning another Lazy or LogValuer.
+const maxLogValueDepth = 8
+
+// resolveValues evaluates lazy values and LogValuers of the fields in place, and encodes values
+// having a registered encoder. Groups having such values are copied, since they may be shared with sub-loggers.
+func resolveValues(fields Fields) Fields {
+	return loadEncoders().resolveFields(fields)
+}
+
+func (r *encoderRegistry) resolveFields(fields Fields) Fields {
+	for i, f := range fields {
+		if group, ok := f.group(); ok {
+			if r.needsResolve(group) {
+				fields[i] = Group(f.Key, r.resolveFields(append(Fields(nil), group...))...)
+			}
+			continue
+		}
+		if !r.isResolvable(f) {
+			continue
+		}
+		fields[i] = Any(f.Key, r.resolveValue(f.Value()))
+		if group, ok := fields[i].group(); ok && r.needsResolve(group) {
+			fields[i] = Group(f.Key, r.resolveFields(append(Fields(nil), group...))...)
+		}
+	}
+	return fields
+}
+
+func (r *encoderRegistry) needsResolve(fields Fields) bool {
+	for _, f := range fields {
+		if group, ok := f.group(); ok && r.needsResolve(group) {
+			return true
+		}
+		if r.isResolvable(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// attrsNeedResolve returns whether any of the attributes, including nested ones, needs to be resolved.
+func (r *encoderRegistry) attrsNeedResolve(attrs Attrs) bool {
+	for key, value := range attrs {
+		if nested, ok := value.(Attrs); ok {
+			if r.attrsNeedResolve(nested) {
+				return true
+			}
+			continue
+		}
+		if r.isResolvable(Field{Key: key, value: value}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *encoderRegistry) isResolvable(f Field) bool {
+	if f.kind != anyField {
+		return r.byKind[f.kind] != nil
+	}
+	switch f.value.(type) {
+	case Lazy, LogValuer:
+		return true
+	case Fields:
+		return false
+	}
+	return r.encoder(f.value) != nil
+}
+
+// resolveValue evaluates the value and encodes the result. A panic is logged in place of the value.
+func resolveValue(value interface{}) interface{} {
+	return loadEncoders().resolveValue(value)
+}
+
+func (r *encoderRegistry) resolveValue(value interface{}) (resolved interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			resolved = fmt.Sprintf("!PANIC(%T): %v", value, p)
+		}
+	}()
+	for i := 0; i < maxLogValueDepth; i++ {
+		switch v := value.(type) {
+		case Lazy:
+			if v == nil {
+				return nil
+			}
+			value = v()
+		case LogValuer:
+			value = v.LogValue()
+		default:
+			if encode := r.encoder(value); encode != nil {
+				return encode(value)
+			}
+			return value
+		}
+	}
+	return value
+}
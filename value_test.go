@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+type valuerTestUser struct {
+	Name     string
+	Password string
+}
+
+func (u valuerTestUser) LogValue() interface{} {
+	return Fields{String("name", u.Name)}
+}
+
+type valuerTestID int
+
+func (id valuerTestID) LogValue() interface{} {
+	return Lazy(func() interface{} { return int(id) * 10 })
+}
+
+type panickingValuer struct{}
+
+func (panickingValuer) LogValue() interface{} {
+	panic("oops")
+}
+
+func TestLogValuer(t *testing.T) {
+	convey.Convey("Given values implementing LogValuer", t, func() {
+		w := &memoryWriter{}
+		l := NewRuntime(w).New("api")
+
+		convey.Convey("Their log values should be used in messages and attributes", func() {
+			l.Info("user {user.name} logged in as {id}", Attrs{"user": valuerTestUser{"alice", "secret"}, "id": valuerTestID(4)})
+			log := w.Logs()[0]
+			convey.So(log.Message, convey.ShouldEqual, "user alice logged in as 40")
+
+			l.Info("logged in", Any("user", valuerTestUser{"bob", "secret"}))
+			convey.So(*w.Logs()[1].Attrs, convey.ShouldResemble, Attrs{"user": Attrs{"name": "bob"}})
+		})
+
+		convey.Convey("Log values in groups should be resolved", func() {
+			l.WithGroup("req").Info("handled", Attrs{"id": valuerTestID(1)})
+			convey.So((*w.Logs()[0].Attrs)["req"], convey.ShouldResemble, Attrs{"id": 10})
+		})
+
+		convey.Convey("Panics should be logged in place of the value", func() {
+			l.Info("{v}", Attrs{"v": panickingValuer{}})
+			convey.So(w.Logs()[0].Message, convey.ShouldEqual, "!PANIC(logger.panickingValuer): oops")
+		})
+	})
+}
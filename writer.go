@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxLineLength bounds the buffer of a line writer. Longer lines are logged in pieces.
+const maxLineLength = 64 * 1024
+
+// WriterOption configures a writer returned by Logger.Writer.
+type WriterOption func(w *lineWriter)
+
+// DetectLevel makes the writer log lines starting with a level like "warning:" or "[ERROR]"
+// with that level, without the prefix.
+func DetectLevel() WriterOption {
+	return func(w *lineWriter) {
+		w.detectLevel = true
+	}
+}
+
+// lineWriter logs every line written to it, for programs and libraries writing to an io.Writer.
+type lineWriter struct {
+	logger      Logger
+	level       *LogLevel
+	detectLevel bool
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+func newLineWriter(l Logger, level *LogLevel, opts []WriterOption) *lineWriter {
+	w := &lineWriter{logger: l, level: level}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.buf[start : start+i]))
+		start += i + 1
+	}
+	for len(w.buf)-start >= maxLineLength {
+		w.emit(string(w.buf[start : start+maxLineLength]))
+		start += maxLineLength
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[start:])]
+	return len(p), nil
+}
+
+// Close logs the last line if it isn't terminated by a newline.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+	w.closed = true
+	return nil
+}
+
+// emit logs the line. Empty lines are skipped.
+func (w *lineWriter) emit(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	level := w.level
+	if w.detectLevel {
+		level, line = detectLevel(line, level)
+	}
+	logText(w.logger, level, line, nil)
+}
+
+// LogCommandOutput logs every line of stdout and stderr of the command with given levels,
+// with the "command" and "pid" attributes. It must be called before starting the command,
+// and the returned Closer should be closed after the command exits to log unterminated last lines.
+//
+//	cmd := exec.Command("git", "fetch")
+//	output := logger.LogCommandOutput(log, cmd, logger.Info, logger.Warn, logger.DetectLevel())
+//	err := cmd.Run()
+//	output.Close()
+func LogCommandOutput(l Logger, cmd *exec.Cmd, stdoutLevel, stderrLevel *LogLevel, opts ...WriterOption) io.Closer {
+	l = l.WithAttrs(Attrs{
+		"command": filepath.Base(cmd.Path),
+		"pid": Lazy(func() interface{} {
+			if cmd.Process == nil {
+				return nil
+			}
+			return cmd.Process.Pid
+		}),
+	})
+	stdout, stderr := l.Writer(stdoutLevel, opts...), l.Writer(stderrLevel, opts...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return closers{stdout, stderr}
+}
+
+type closers []io.Closer
+
+func (cs closers) Close() error {
+	var first error
+	for _, c := range cs {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
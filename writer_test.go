@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriter(t *testing.T) {
+	convey.Convey("Given a writer of a logger", t, func() {
+		w := &memoryWriter{}
+		l := NewRuntime(w).New("child")
+
+		messages := func() (messages []string) {
+			for _, log := range w.Logs() {
+				messages = append(messages, log.Level.Name+" "+log.Message)
+			}
+			return
+		}
+
+		convey.Convey("Each line should be logged once terminated", func() {
+			out := l.Writer(Info)
+			io.WriteString(out, "first {line}\r\nsecond ")
+			convey.So(messages(), convey.ShouldResemble, []string{"INFO first {line}"})
+
+			io.WriteString(out, "line\n\nthird")
+			convey.So(out.Close(), convey.ShouldBeNil)
+			convey.So(messages(), convey.ShouldResemble, []string{"INFO first {line}", "INFO second line", "INFO third"})
+
+			_, err := io.WriteString(out, "closed\n")
+			convey.So(err, convey.ShouldEqual, io.ErrClosedPipe)
+		})
+
+		convey.Convey("Levels should be detected if enabled", func() {
+			out := l.WithAttrs(Attrs{"stream": "stderr"}).Writer(Debug, DetectLevel())
+			io.WriteString(out, "warning: low memory\nstarting\n")
+			convey.So(messages(), convey.ShouldResemble, []string{"WARN low memory", "DEBUG starting"})
+			convey.So((*w.Logs()[0].Attrs)["stream"], convey.ShouldEqual, "stderr")
+		})
+
+		convey.Convey("Long lines should be logged in pieces", func() {
+			out := l.Writer(Info)
+			io.WriteString(out, strings.Repeat("a", maxLineLength+1))
+			out.Close()
+			logs := w.Logs()
+			convey.So(logs, convey.ShouldHaveLength, 2)
+			convey.So(logs[0].Message, convey.ShouldHaveLength, maxLineLength)
+		})
+	})
+}
+
+func TestLogCommandOutput(t *testing.T) {
+	convey.Convey("Output of a command should be logged with its pid", t, func() {
+		if _, err := exec.LookPath("sh"); err != nil {
+			return
+		}
+		w := &memoryWriter{}
+		l := NewRuntime(w).New("child")
+
+		cmd := exec.Command("sh", "-c", "echo hello; echo 'error: oops' >&2; printf partial")
+		output := LogCommandOutput(l, cmd, Info, Warn, DetectLevel())
+		convey.So(cmd.Run(), convey.ShouldBeNil)
+		convey.So(output.Close(), convey.ShouldBeNil)
+
+		byMessage := map[string]*Log{}
+		for _, log := range w.Logs() {
+			byMessage[log.Message] = log
+		}
+		convey.So(byMessage, convey.ShouldHaveLength, 3)
+		convey.So(byMessage["hello"].Level, convey.ShouldEqual, Info)
+		convey.So(byMessage["oops"].Level, convey.ShouldEqual, Error)
+		convey.So(byMessage["partial"].Level, convey.ShouldEqual, Info)
+
+		attrs := *byMessage["hello"].Attrs
+		convey.So(attrs["command"], convey.ShouldEqual, "sh")
+		convey.So(attrs["pid"], convey.ShouldEqual, cmd.Process.Pid)
+	})
+}